@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+)
+
+// AnomalyDetectors watch a value read from a metric, such as a meter's rate
+// or a timer's percentile, and flag readings which stray too far from its
+// exponentially-weighted moving average.  Tick reads the value and updates
+// the score and the moving average.
+type AnomalyDetector interface {
+	Tickable
+
+	// Return whether the last reading was anomalous.
+	Anomalous() bool
+
+	// Return the gauge of the distance of the last reading from the moving
+	// average, in hundredths of a moving standard deviation.
+	Score() Gauge
+}
+
+// The standard implementation of an AnomalyDetector keeps an exponentially
+// weighted moving mean and variance of the readings and scores each reading
+// against them before it is added.
+type anomalyDetector struct {
+	read      func() float64
+	alpha     float64
+	threshold float64
+	f         func(score float64, anomalous bool)
+	score     Gauge
+	mutex     sync.Mutex
+	n         int
+	anomalous bool
+	mean      float64
+	variance  float64
+}
+
+// The least moving standard deviation readings are scored against, relative
+// to the moving average, so that a flat baseline does not hide a spike.
+const anomalyStdDevFloor = 0.01
+
+// Create a new anomaly detector reading values with read, which must not be
+// nil.  The moving average weighs each reading by alpha, which must be in
+// (0, 1).  Once about 1/alpha values have been read, a reading more than
+// threshold moving standard deviations from the average, which must be
+// positive, is anomalous.  The standard deviation is taken to be at least 1%
+// of the average, and any change of a baseline which is constantly zero
+// scores infinity.  Readings which are NaN or infinite are skipped.  If f is
+// not nil, it is called from Tick with the score in standard deviations
+// whenever readings become or stop being anomalous.
+func NewAnomalyDetector(read func() float64, alpha, threshold float64, f func(score float64, anomalous bool)) (AnomalyDetector, error) {
+	if nil == read {
+		return nil, invalidf("read must not be nil")
+	}
+	if err := checkAlpha(alpha); err != nil {
+		return nil, err
+	}
+	if !(threshold > 0) {
+		return nil, invalidf("threshold must be positive, got %v", threshold)
+	}
+	return &anomalyDetector{
+		read:      read,
+		alpha:     alpha,
+		threshold: threshold,
+		f:         f,
+		score:     NewGauge(),
+	}, nil
+}
+
+// Like NewAnomalyDetector but panics if the arguments are invalid.
+func MustNewAnomalyDetector(read func() float64, alpha, threshold float64, f func(score float64, anomalous bool)) AnomalyDetector {
+	a, err := NewAnomalyDetector(read, alpha, threshold, f)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (a *anomalyDetector) Anomalous() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.anomalous
+}
+
+func (a *anomalyDetector) Score() Gauge {
+	return a.score
+}
+
+func (a *anomalyDetector) Tick() {
+	x := a.read()
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return
+	}
+	a.mutex.Lock()
+	score := a.update(x)
+	anomalous := score > a.threshold
+	changed := anomalous != a.anomalous
+	a.anomalous = anomalous
+	a.mutex.Unlock()
+	a.score.Update(hundredths(score))
+	if changed && a.f != nil {
+		a.f(score, anomalous)
+	}
+}
+
+// Score x against the moving average and add it; must be called with the
+// mutex held.  The score stays zero for the first 1/alpha readings, while
+// the average warms up.
+func (a *anomalyDetector) update(x float64) float64 {
+	if a.n++; 1 == a.n {
+		a.mean = x
+		return 0
+	}
+	d := x - a.mean
+	var score float64
+	if float64(a.n) > 1/a.alpha && 0 != d {
+		sd := math.Max(math.Sqrt(a.variance), anomalyStdDevFloor*math.Abs(a.mean))
+		score = math.Abs(d) / sd // +Inf for a change of a zero baseline
+	}
+	a.mean += a.alpha * d
+	a.variance = (1 - a.alpha) * (a.variance + a.alpha*d*d)
+	return score
+}
+
+// Return a score in hundredths, as the score gauge holds it, saturating
+// instead of overflowing.
+func hundredths(score float64) int64 {
+	return saturate(score * 100)
+}
+
+// Round x to the nearest int64, saturating instead of overflowing.
+func saturate(x float64) int64 {
+	switch x = math.Round(x); {
+	case x >= math.MaxInt64:
+		return math.MaxInt64
+	case x <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(x)
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	var x float64
+	var calls []bool
+	a := MustNewAnomalyDetector(func() float64 { return x }, 0.1, 3, func(score float64, anomalous bool) {
+		calls = append(calls, anomalous)
+	})
+	for i := 0; i < 100; i++ {
+		x = float64(10 + i%2)
+		a.Tick()
+	}
+	if a.Anomalous() {
+		t.Error("a.Anomalous(): true during steady readings")
+	}
+	if score := a.Score().Value(); score > 300 {
+		t.Errorf("a.Score().Value(): %v > 300\n", score)
+	}
+	x = 20
+	a.Tick()
+	if !a.Anomalous() {
+		t.Error("a.Anomalous(): false after a spike")
+	}
+	if score := a.Score().Value(); score <= 300 {
+		t.Errorf("a.Score().Value(): %v <= 300\n", score)
+	}
+	x = 10
+	a.Tick()
+	if a.Anomalous() {
+		t.Error("a.Anomalous(): true after the spike")
+	}
+	if 2 != len(calls) || !calls[0] || calls[1] {
+		t.Errorf("calls: [true false] != %v\n", calls)
+	}
+}
+
+func TestAnomalyDetectorInvalidArguments(t *testing.T) {
+	read := func() float64 { return 0 }
+	if _, err := NewAnomalyDetector(read, 1, 3, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewAnomalyDetector(read, 1, 3, nil): %v is not ErrInvalidArgument", err)
+	}
+	if _, err := NewAnomalyDetector(read, 0.1, 0, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewAnomalyDetector(read, 0.1, 0, nil): %v is not ErrInvalidArgument", err)
+	}
+	if _, err := NewAnomalyDetector(nil, 0.1, 3, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewAnomalyDetector(nil, 0.1, 3, nil): %v is not ErrInvalidArgument", err)
+	}
+}
+
+func TestAnomalyDetectorFlatBaseline(t *testing.T) {
+	for _, baseline := range []float64{10, 0} {
+		x := baseline
+		a := MustNewAnomalyDetector(func() float64 { return x }, 0.1, 3, nil)
+		for i := 0; i < 100; i++ {
+			a.Tick()
+		}
+		if a.Anomalous() {
+			t.Errorf("a.Anomalous(): true during flat readings of %v\n", baseline)
+		}
+		x = baseline + 1
+		a.Tick()
+		if !a.Anomalous() {
+			t.Errorf("a.Anomalous(): false after a spike from a flat %v\n", baseline)
+		}
+		if score := a.Score().Value(); score <= 300 {
+			t.Errorf("a.Score().Value(): %v <= 300\n", score)
+		}
+	}
+}
+
+func TestAnomalyDetectorNonFinite(t *testing.T) {
+	x := 10.0
+	a := MustNewAnomalyDetector(func() float64 { return x }, 0.1, 3, nil)
+	for i := 0; i < 100; i++ {
+		a.Tick()
+	}
+	for _, x = range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		a.Tick()
+		if a.Anomalous() {
+			t.Errorf("a.Anomalous(): true after reading %v\n", x)
+		}
+	}
+	x = 10
+	a.Tick()
+	if score := a.Score().Value(); 0 != score {
+		t.Errorf("a.Score().Value(): 0 != %v\n", score)
+	}
+	x = 20
+	a.Tick()
+	if !a.Anomalous() {
+		t.Error("a.Anomalous(): false after a spike following non-finite readings")
+	}
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// BatchedCounters accumulate increments on the hot path and add them to a
+// Counter when flushed, trading a little staleness for much less contention
+// between goroutines.
+type BatchedCounter interface {
+	// Add the accumulated increments to the counter.
+	Flush()
+
+	// Accumulate an increment.
+	Inc(n int64)
+
+	// Flush, so that batched counters can be driven like other Tickables.
+	Tick()
+}
+
+// BatchedMeters accumulate marks on the hot path and mark a Meter with them
+// when flushed.  The meter sees one mark per flush, so its LastUpdate and
+// inter-arrival times describe flushes rather than events, and it still
+// needs to be ticked itself.
+type BatchedMeter interface {
+	// Mark the meter with the accumulated events.
+	Flush()
+
+	// Accumulate n events.
+	Mark(n int64)
+
+	// Flush, so that batched meters can be driven like other Tickables.
+	Tick()
+}
+
+type batchedCounter struct {
+	c Counter
+	a *accumulator
+}
+
+// Create a new batched counter which adds to c.  Flush it on a short
+// interval; increments not yet flushed do not show in c.
+func NewBatchedCounter(c Counter) BatchedCounter {
+	return &batchedCounter{c: c, a: newAccumulator()}
+}
+
+func (b *batchedCounter) Flush() {
+	if n := b.a.drain(); 0 != n {
+		b.c.Inc(n)
+	}
+}
+
+func (b *batchedCounter) Inc(n int64) {
+	b.a.add(n)
+}
+
+func (b *batchedCounter) Tick() {
+	b.Flush()
+}
+
+type batchedMeter struct {
+	m Meter
+	a *accumulator
+}
+
+// Create a new batched meter which marks m.  Flush it on a short interval,
+// well below the meter's tick interval; marks not yet flushed do not show in
+// m.
+func NewBatchedMeter(m Meter) BatchedMeter {
+	return &batchedMeter{m: m, a: newAccumulator()}
+}
+
+func (b *batchedMeter) Flush() {
+	if n := b.a.drain(); 0 != n {
+		b.m.Mark(n)
+	}
+}
+
+func (b *batchedMeter) Mark(n int64) {
+	b.a.add(n)
+}
+
+func (b *batchedMeter) Tick() {
+	b.Flush()
+}
+
+// An accumulator spreads additions over one shard per P.  A sync.Pool hands
+// out shards so that a goroutine usually gets the one its P used last and
+// does not share a cache line with other Ps.  The pool only lends shards,
+// which all stay in the shards slice, so nothing is lost when it is emptied
+// by the garbage collector.
+type accumulator struct {
+	shards []shard
+	next   atomic.Uint32
+	pool   sync.Pool
+}
+
+// A shard is padded to a cache line of its own.
+type shard struct {
+	n int64
+	_ [56]byte
+}
+
+func newAccumulator() *accumulator {
+	a := &accumulator{shards: make([]shard, runtime.GOMAXPROCS(0))}
+	a.pool.New = func() interface{} {
+		return &a.shards[int(a.next.Add(1)-1)%len(a.shards)]
+	}
+	return a
+}
+
+func (a *accumulator) add(n int64) {
+	s := a.pool.Get().(*shard)
+	atomic.AddInt64(&s.n, n)
+	a.pool.Put(s)
+}
+
+// Return the sum of all additions since the last drain.
+func (a *accumulator) drain() int64 {
+	var n int64
+	for i := range a.shards {
+		n += atomic.SwapInt64(&a.shards[i].n, 0)
+	}
+	return n
+}
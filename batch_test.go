@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBatchedCounter(t *testing.T) {
+	c := NewCounter()
+	b := NewBatchedCounter(c)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				b.Inc(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+	b.Flush()
+	if count := c.Count(); 8000 != count {
+		t.Errorf("c.Count(): 8000 != %v\n", count)
+	}
+	b.Tick()
+	if count := c.Count(); 8000 != count {
+		t.Errorf("c.Count(): 8000 != %v\n", count)
+	}
+}
+
+func TestBatchedMeter(t *testing.T) {
+	m := MustNewMeter()
+	b := NewBatchedMeter(m)
+	b.Mark(2)
+	b.Mark(3)
+	b.Flush()
+	if count := m.Count(); 5 != count {
+		t.Errorf("m.Count(): 5 != %v\n", count)
+	}
+}
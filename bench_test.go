@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Benchmarks in this file run every operation from 1, 8 and 64 goroutines
+// to expose contention.  Use benchcmp.sh to compare two revisions.
+
+func BenchmarkCounterIncParallel(b *testing.B) {
+	c := NewCounter()
+	benchmarkGoroutines(b, func() { c.Inc(1) })
+}
+
+func BenchmarkBatchedCounterIncParallel(b *testing.B) {
+	c := NewBatchedCounter(NewCounter())
+	benchmarkGoroutines(b, func() { c.Inc(1) })
+}
+
+func BenchmarkGaugeUpdateParallel(b *testing.B) {
+	g := NewGauge()
+	benchmarkGoroutines(b, func() { g.Update(1) })
+}
+
+func BenchmarkEWMAUpdateParallel(b *testing.B) {
+	a := NewEWMA1()
+	benchmarkGoroutines(b, func() { a.Update(1) })
+}
+
+func BenchmarkMeterMarkParallel(b *testing.B) {
+	m := MustNewMeter()
+	benchmarkGoroutines(b, func() { m.Mark(1) })
+}
+
+func BenchmarkMeterSnapshotAndResetParallel(b *testing.B) {
+	m := MustNewMeter()
+	benchmarkGoroutines(b, func() {
+		m.Mark(1)
+		m.SnapshotAndReset()
+	})
+}
+
+func BenchmarkBatchedMeterMarkParallel(b *testing.B) {
+	m := NewBatchedMeter(MustNewMeter())
+	benchmarkGoroutines(b, func() { m.Mark(1) })
+}
+
+func BenchmarkMeterRateParallel(b *testing.B) {
+	m := MustNewMeter()
+	m.Mark(1)
+	m.Tick()
+	benchmarkGoroutines(b, func() { m.Rate1() })
+}
+
+func BenchmarkUniformHistogramUpdateParallel(b *testing.B) {
+	h := MustNewHistogram(MustNewUniformSample(1028))
+	benchmarkGoroutines(b, func() { h.Update(1) })
+}
+
+func BenchmarkExpDecayHistogramUpdateParallel(b *testing.B) {
+	h := MustNewHistogram(MustNewExpDecaySample(1028, 0.015))
+	benchmarkGoroutines(b, func() { h.Update(1) })
+}
+
+func BenchmarkHistogramUpdateLoop100Parallel(b *testing.B) {
+	h := MustNewHistogram(MustNewExpDecaySample(1028, 0.015))
+	vs := make([]int64, 100)
+	benchmarkGoroutines(b, func() {
+		for _, v := range vs {
+			h.Update(v)
+		}
+	})
+}
+
+func BenchmarkHistogramUpdateBatch100Parallel(b *testing.B) {
+	h := MustNewHistogram(MustNewExpDecaySample(1028, 0.015))
+	vs := make([]int64, 100)
+	benchmarkGoroutines(b, func() { h.UpdateBatch(vs) })
+}
+
+func BenchmarkHistogramPercentilesParallel(b *testing.B) {
+	h := MustNewHistogram(MustNewExpDecaySample(1028, 0.015))
+	for i := 0; i < 1028; i++ {
+		h.Update(int64(i))
+	}
+	ps := []float64{0.5, 0.75, 0.95, 0.99}
+	benchmarkGoroutines(b, func() { h.Percentiles(ps) })
+}
+
+func BenchmarkHistogramSnapshotAndResetParallel(b *testing.B) {
+	h := MustNewHistogram(MustNewExpDecaySample(1028, 0.015))
+	benchmarkGoroutines(b, func() {
+		h.Update(1)
+		h.SnapshotAndReset()
+	})
+}
+
+func BenchmarkTimerUpdateParallel(b *testing.B) {
+	tm := MustNewTimer()
+	benchmarkGoroutines(b, func() { tm.Update(time.Millisecond) })
+}
+
+func BenchmarkTimerWithoutReservoirUpdateParallel(b *testing.B) {
+	tm := MustNewTimer(WithoutReservoir())
+	benchmarkGoroutines(b, func() { tm.Update(time.Millisecond) })
+}
+
+func BenchmarkTimerPercentilesParallel(b *testing.B) {
+	tm := benchmarkTimer()
+	ps := []float64{0.5, 0.75, 0.95, 0.99}
+	benchmarkGoroutines(b, func() { tm.Percentiles(ps) })
+}
+
+func BenchmarkTimerSnapshotAndResetParallel(b *testing.B) {
+	tm := MustNewTimer()
+	benchmarkGoroutines(b, func() {
+		tm.Update(time.Millisecond)
+		tm.SnapshotAndReset()
+	})
+}
+
+func BenchmarkTimerStringParallel(b *testing.B) {
+	tm := benchmarkTimer()
+	benchmarkGoroutines(b, func() { _ = fmt.Sprint(tm) })
+}
+
+func BenchmarkTimerMarshalJSONParallel(b *testing.B) {
+	tm := benchmarkTimer()
+	benchmarkGoroutines(b, func() { json.Marshal(tm) })
+}
+
+// Return a timer with a full reservoir.
+func benchmarkTimer() Timer {
+	tm := MustNewTimer()
+	for i := 0; i < 1028; i++ {
+		tm.Update(time.Duration(i) * time.Microsecond)
+	}
+	return tm
+}
+
+// Run f b.N times in total, split between 1, 8 and 64 goroutines in
+// separate sub-benchmarks.
+func benchmarkGoroutines(b *testing.B, f func()) {
+	for _, n := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("goroutines=%d", n), func(b *testing.B) {
+			var wg sync.WaitGroup
+			wg.Add(n)
+			for i := 0; i < n; i++ {
+				count := b.N / n
+				if i < b.N%n {
+					count++
+				}
+				go func() {
+					defer wg.Done()
+					for j := 0; j < count; j++ {
+						f()
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,49 @@
+#!/bin/sh
+# Compare benchmarks of two revisions of this package.
+#
+# Usage: ./benchcmp.sh [old-rev [new-rev]]
+#
+# Revisions default to HEAD~1 and the working tree.  Extra flags for
+# "go test" can be passed in BENCHFLAGS, e.g. BENCHFLAGS='-bench Parallel'.
+# Results are compared with benchstat (golang.org/x/perf/cmd/benchstat) if
+# it is installed, otherwise both are printed.
+set -e
+
+old=${1:-HEAD~1}
+new=$2
+flags=${BENCHFLAGS:--bench .}
+count=${COUNT:-10}
+root=$(git -C "$(dirname "$0")" rev-parse --show-toplevel)
+tmp=$(mktemp -d)
+trap 'git -C "$root" worktree remove --force "$tmp/old" >/dev/null 2>&1 || true
+	git -C "$root" worktree remove --force "$tmp/new" >/dev/null 2>&1 || true
+	rm -rf "$tmp"' EXIT
+
+# The package has no go.mod, so outside of GOPATH it has to be built with
+# modules off.
+run() {
+	if [ -f "$1/go.mod" ]; then
+		modules=on
+	else
+		modules=off
+	fi
+	(cd "$1" && GO111MODULE=$modules go test -run '^$' $flags -count "$count" .) >"$2"
+}
+
+git -C "$root" worktree add --detach "$tmp/old" "$old" >/dev/null
+run "$tmp/old" "$tmp/old.txt"
+if [ -n "$new" ]; then
+	git -C "$root" worktree add --detach "$tmp/new" "$new" >/dev/null
+	run "$tmp/new" "$tmp/new.txt"
+else
+	run "$root" "$tmp/new.txt"
+fi
+
+if command -v benchstat >/dev/null 2>&1; then
+	benchstat "$tmp/old.txt" "$tmp/new.txt"
+else
+	echo "benchstat not found; old results:" >&2
+	cat "$tmp/old.txt"
+	echo "new results:" >&2
+	cat "$tmp/new.txt"
+fi
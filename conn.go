@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectionMetrics instrument long-lived connections such as WebSocket or
+// server-sent events streams: how many are open, how long they last and how
+// many messages they carry, in total and per connection.
+type ConnectionMetrics interface {
+	// Return the gauge of open connections.
+	Active() Gauge
+
+	// Return the timer of connection durations, updated when connections
+	// close.
+	Durations() Timer
+
+	// Return the meter of messages over all connections.
+	Messages() Meter
+
+	// Open a connection, which must be closed when it ends.  If the
+	// WithInterArrival factory returns nil, the connection's meter does
+	// without inter-arrival times.
+	Open() Connection
+
+	// Tick the meters of all open connections, the total messages meter and
+	// the durations timer.
+	Tick()
+}
+
+// Connections are opened by ConnectionMetrics.
+type Connection interface {
+	// Close the connection, recording its duration.  Closing a closed
+	// connection does nothing.
+	Close()
+
+	// Record n messages sent or received on the connection.
+	Message(n int64)
+
+	// Return the meter of messages on this connection.
+	Messages() Meter
+}
+
+// The standard implementation of ConnectionMetrics keeps open connections in
+// a mutex-protected set to tick them and to count them for the gauge.
+type connectionMetrics struct {
+	active    Gauge
+	durations Timer
+	messages  Meter
+	now       func() time.Time
+	opts      []Option
+	mutex     sync.Mutex
+	open      map[*connection]struct{}
+}
+
+// Create new connection metrics.  Options are passed on to the active
+// gauge, the durations timer and the meters; each meter gets a sample of its
+// own from WithInterArrival.
+func NewConnectionMetrics(opts ...Option) (ConnectionMetrics, error) {
+	durations, err := NewTimer(opts...)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := NewMeter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &connectionMetrics{
+		active:    NewGauge(opts...),
+		durations: durations,
+		messages:  messages,
+		now:       newOptions(opts).now,
+		opts:      opts,
+		open:      make(map[*connection]struct{}),
+	}, nil
+}
+
+// Like NewConnectionMetrics but panics if the options are invalid.
+func MustNewConnectionMetrics(opts ...Option) ConnectionMetrics {
+	c, err := NewConnectionMetrics(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *connectionMetrics) Active() Gauge {
+	return c.active
+}
+
+func (c *connectionMetrics) Durations() Timer {
+	return c.durations
+}
+
+func (c *connectionMetrics) Messages() Meter {
+	return c.messages
+}
+
+func (c *connectionMetrics) Open() Connection {
+	m, err := NewMeter(c.opts...)
+	if err != nil {
+		// The options were valid for the messages meter, so only the
+		// inter-arrival sample factory can have failed.
+		opts := append(c.opts[:len(c.opts):len(c.opts)], WithInterArrival(nil))
+		m = MustNewMeter(opts...)
+	}
+	conn := &connection{c: c, m: m, start: c.now()}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.open[conn] = struct{}{}
+	c.active.Update(int64(len(c.open)))
+	return conn
+}
+
+func (c *connectionMetrics) Tick() {
+	c.mutex.Lock()
+	for conn := range c.open {
+		conn.m.Tick()
+	}
+	c.mutex.Unlock()
+	c.messages.Tick()
+	c.durations.Tick()
+}
+
+func (c *connectionMetrics) close(conn *connection) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.open[conn]; !ok {
+		return
+	}
+	delete(c.open, conn)
+	c.active.Update(int64(len(c.open)))
+	c.durations.Update(c.now().Sub(conn.start))
+}
+
+type connection struct {
+	c     *connectionMetrics
+	m     Meter
+	start time.Time
+}
+
+func (conn *connection) Close() {
+	conn.c.close(conn)
+}
+
+func (conn *connection) Message(n int64) {
+	conn.m.Mark(n)
+	conn.c.messages.Mark(n)
+}
+
+func (conn *connection) Messages() Meter {
+	return conn.m
+}
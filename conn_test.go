@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnectionMetrics(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := MustNewConnectionMetrics(WithClock(func() time.Time { return now }))
+	a := c.Open()
+	b := c.Open()
+	if v := c.Active().Value(); 2 != v {
+		t.Errorf("c.Active().Value(): 2 != %v\n", v)
+	}
+	a.Message(2)
+	b.Message(3)
+	c.Tick()
+	if count := a.Messages().Count(); 2 != count {
+		t.Errorf("a.Messages().Count(): 2 != %v\n", count)
+	}
+	if count := c.Messages().Count(); 5 != count {
+		t.Errorf("c.Messages().Count(): 5 != %v\n", count)
+	}
+	if r1 := b.Messages().Rate1(); 0.6 != r1 {
+		t.Errorf("b.Messages().Rate1(): 0.6 != %v\n", r1)
+	}
+	now = now.Add(time.Second)
+	a.Close()
+	a.Close()
+	if v := c.Active().Value(); 1 != v {
+		t.Errorf("c.Active().Value(): 1 != %v\n", v)
+	}
+	if count := c.Durations().Count(); 1 != count {
+		t.Errorf("c.Durations().Count(): 1 != %v\n", count)
+	}
+	if max := c.Durations().Max(); int64(time.Second) != max {
+		t.Errorf("c.Durations().Max(): %v != %v\n", int64(time.Second), max)
+	}
+}
+
+func TestConnectionMetricsInvalidOptions(t *testing.T) {
+	if _, err := NewConnectionMetrics(WithTickInterval(0)); nil == err {
+		t.Error("NewConnectionMetrics(WithTickInterval(0)): no error")
+	}
+}
+
+func TestConnectionMetricsNilInterArrival(t *testing.T) {
+	// The durations timer and the messages meter get samples, connections
+	// do not.
+	calls := 0
+	c := MustNewConnectionMetrics(WithInterArrival(func() Sample {
+		if calls++; calls > 2 {
+			return nil
+		}
+		return MustNewUniformSample(100)
+	}))
+	conn := c.Open()
+	conn.Message(1)
+	if count := conn.Messages().Count(); 1 != count {
+		t.Errorf("conn.Messages().Count(): 1 != %v\n", count)
+	}
+	if h := conn.Messages().InterArrival(); nil != h {
+		t.Errorf("conn.Messages().InterArrival(): nil != %v\n", h)
+	}
+	conn.Close()
+}
+
+func TestConnectionMetricsConcurrent(t *testing.T) {
+	c := MustNewConnectionMetrics(WithInterArrival(func() Sample { return MustNewUniformSample(100) }))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				conn := c.Open()
+				conn.Message(1)
+				conn.Message(1)
+				if count := conn.Messages().InterArrival().Count(); 1 != count {
+					t.Errorf("conn.Messages().InterArrival().Count(): 1 != %v\n", count)
+				}
+				c.Tick()
+				conn.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if count := c.Messages().Count(); 1600 != count {
+		t.Errorf("c.Messages().Count(): 1600 != %v\n", count)
+	}
+	if count := c.Messages().InterArrival().Count(); 1599 != count {
+		t.Errorf("c.Messages().InterArrival().Count(): 1599 != %v\n", count)
+	}
+	if count := c.Durations().Count(); 800 != count {
+		t.Errorf("c.Durations().Count(): 800 != %v\n", count)
+	}
+	if v := c.Active().Value(); 0 != v {
+		t.Errorf("c.Active().Value(): 0 != %v\n", v)
+	}
+}
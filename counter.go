@@ -1,6 +1,9 @@
 package metrics
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // Counters hold an int64 value that can be incremented and decremented.
 type Counter interface {
@@ -10,22 +13,39 @@ type Counter interface {
 	// Return the current count.
 	Count() int64
 
+	// Return the time the counter was created.
+	Created() time.Time
+
 	// Decrement the counter by the given amount.
 	Dec(amount int64) Counter
 
 	// Increment the counter by the given amount.
 	Inc(amount int64) Counter
+
+	// Return the time the counter was last updated, or the zero time if it
+	// never was or was created without WithLastUpdate.
+	LastUpdate() time.Time
+
+	// Atomically return the current count as a read-only counter and set the
+	// counter to zero.  Calling Clear, Dec, Inc or SnapshotAndReset on the
+	// returned counter panics.
+	SnapshotAndReset() Counter
 }
 
 // The standard implementation of a Counter uses the sync/atomic package
 // to manage a single int64 value.
 type counter struct {
 	count int64
+	timestamps
 }
 
-// Create a new counter.
-func NewCounter() Counter {
-	return &counter{count: 0}
+// Create a new counter.  It honors the WithClock and WithLastUpdate
+// options; a nil clock means time.Now.
+func NewCounter(opts ...Option) Counter {
+	o := newOptions(opts)
+	c := &counter{}
+	c.init(&o)
+	return c
 }
 
 func (c *counter) Clear() {
@@ -38,10 +58,55 @@ func (c *counter) Count() int64 {
 
 func (c *counter) Dec(i int64) Counter {
 	atomic.AddInt64(&c.count, -i)
+	c.touch()
 	return c
 }
 
 func (c *counter) Inc(i int64) Counter {
 	atomic.AddInt64(&c.count, i)
+	c.touch()
 	return c
 }
+
+func (c *counter) SnapshotAndReset() Counter {
+	return &counterSnapshot{
+		count:            atomic.SwapInt64(&c.count, 0),
+		frozenTimestamps: c.snapshot(),
+	}
+}
+
+// A read-only copy of a counter's count.
+type counterSnapshot struct {
+	count int64
+	frozenTimestamps
+}
+
+func (c *counterSnapshot) Clear() {
+	panic("metrics: Clear called on a counter snapshot")
+}
+
+func (c *counterSnapshot) Count() int64 { return c.count }
+
+func (c *counterSnapshot) Dec(int64) Counter {
+	panic("metrics: Dec called on a counter snapshot")
+}
+
+func (c *counterSnapshot) Inc(int64) Counter {
+	panic("metrics: Inc called on a counter snapshot")
+}
+
+func (c *counterSnapshot) SnapshotAndReset() Counter {
+	panic("metrics: SnapshotAndReset called on a counter snapshot")
+}
+
+func (c *counter) String() string { return formatCounter(c) }
+
+func (c *counterSnapshot) String() string { return formatCounter(c) }
+
+func (c *counter) MarshalJSON() ([]byte, error) { return marshalCounter(c) }
+
+func (c *counter) MarshalText() ([]byte, error) { return []byte(formatCounter(c)), nil }
+
+func (c *counterSnapshot) MarshalJSON() ([]byte, error) { return marshalCounter(c) }
+
+func (c *counterSnapshot) MarshalText() ([]byte, error) { return []byte(formatCounter(c)), nil }
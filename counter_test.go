@@ -1,6 +1,10 @@
 package metrics
 
-import "testing"
+import (
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestCounterZero(t *testing.T) {
 	c := NewCounter()
@@ -59,3 +63,84 @@ func TestCounterIncDec(t *testing.T) {
 		t.Errorf("c.Count(): 1 != %v\n", count)
 	}
 }
+
+func TestCounterSnapshotAndReset(t *testing.T) {
+	c := NewCounter()
+	c.Inc(5)
+	s := c.SnapshotAndReset()
+	if count := s.Count(); 5 != count {
+		t.Errorf("s.Count(): 5 != %v\n", count)
+	}
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+}
+
+func TestCounterSnapshotAndResetConcurrent(t *testing.T) {
+	c := NewCounter()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc(1)
+			}
+		}()
+	}
+	var total int64
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			total += c.SnapshotAndReset().Count()
+			if 8000 != total {
+				t.Errorf("total: 8000 != %v\n", total)
+			}
+			return
+		default:
+			total += c.SnapshotAndReset().Count()
+		}
+	}
+}
+
+func TestCounterTimestamps(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewCounter(WithClock(func() time.Time { return now }), WithLastUpdate())
+	if ts := c.LastUpdate(); !ts.IsZero() {
+		t.Errorf("c.LastUpdate(): zero time != %v\n", ts)
+	}
+	c.Inc(1)
+	if ts := c.LastUpdate(); !ts.Equal(now) {
+		t.Errorf("c.LastUpdate(): %v != %v\n", now, ts)
+	}
+	if ts := c.Created(); !ts.Equal(now) {
+		t.Errorf("c.Created(): %v != %v\n", now, ts)
+	}
+	now = now.Add(time.Second)
+	c.Dec(1)
+	if ts := c.SnapshotAndReset().LastUpdate(); !ts.Equal(now) {
+		t.Errorf("snapshot LastUpdate(): %v != %v\n", now, ts)
+	}
+	c = NewCounter()
+	c.Inc(1)
+	if ts := c.LastUpdate(); !ts.IsZero() {
+		t.Errorf("c.LastUpdate() without WithLastUpdate: zero time != %v\n", ts)
+	}
+}
+
+func TestCounterNilClock(t *testing.T) {
+	before := time.Now()
+	c := NewCounter(WithClock(nil), WithLastUpdate())
+	c.Inc(1)
+	if ts := c.Created(); ts.Before(before) {
+		t.Errorf("c.Created() with a nil clock: %v before %v\n", ts, before)
+	}
+	if ts := c.LastUpdate(); ts.Before(before) {
+		t.Errorf("c.LastUpdate() with a nil clock: %v before %v\n", ts, before)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // EWMAs continuously calculate an exponentially-weighted moving average
 // based on an outside source of clock ticks.
 type EWMA interface {
+	// Clear the EWMA: drop uncounted events and set the rate to zero.
+	Clear()
+
 	// Return the moving average rate of events per second.
 	Rate() float64
 
@@ -24,30 +28,67 @@ type EWMA interface {
 // to manage uncounted events.
 type ewma struct {
 	alpha     float64
+	interval  time.Duration
 	rate      float64
 	uncounted int64
 	init      bool
 	mutex     sync.RWMutex
 }
 
-// Create a new EWMA with the given alpha.
-func NewEWMA(alpha float64) EWMA {
-	return &ewma{alpha: alpha}
+// Create a new EWMA with the given alpha, which must be in (0, 1).
+func NewEWMA(alpha float64) (EWMA, error) {
+	if err := checkAlpha(alpha); err != nil {
+		return nil, err
+	}
+	return newEWMA(alpha, TickDuration), nil
+}
+
+// Like NewEWMA but panics if alpha is invalid.
+func MustNewEWMA(alpha float64) EWMA {
+	a, err := NewEWMA(alpha)
+	if err != nil {
+		panic(err)
+	}
+	return a
 }
 
 // Create a new EWMA with alpha set for a one-minute moving average.
 func NewEWMA1() EWMA {
-	return NewEWMA(1 - math.Exp(-5.0/60.0/1))
+	return newEWMA(ewmaAlpha(TickDuration, 1), TickDuration)
 }
 
 // Create a new EWMA with alpha set for a five-minute moving average.
 func NewEWMA5() EWMA {
-	return NewEWMA(1 - math.Exp(-5.0/60.0/5))
+	return newEWMA(ewmaAlpha(TickDuration, 5), TickDuration)
 }
 
 // Create a new EWMA with alpha set for a fifteen-minute moving average.
 func NewEWMA15() EWMA {
-	return NewEWMA(1 - math.Exp(-5.0/60.0/15))
+	return newEWMA(ewmaAlpha(TickDuration, 15), TickDuration)
+}
+
+// Create a new EWMA with the given alpha which is ticked every interval.
+func newEWMA(alpha float64, interval time.Duration) *ewma {
+	return &ewma{alpha: alpha, interval: interval}
+}
+
+// Return the alpha of a moving average over the given number of minutes
+// which is ticked every interval.
+func ewmaAlpha(interval time.Duration, minutes float64) float64 {
+	return 1 - math.Exp(-interval.Seconds()/60.0/minutes)
+}
+
+func (a *ewma) Clear() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	atomic.StoreInt64(&a.uncounted, 0)
+	a.rate = 0
+	a.init = false
+}
+
+// Return the uncounted events and drop them.
+func (a *ewma) takeUncounted() int64 {
+	return atomic.SwapInt64(&a.uncounted, 0)
 }
 
 func (a *ewma) Rate() float64 {
@@ -57,11 +98,10 @@ func (a *ewma) Rate() float64 {
 }
 
 func (a *ewma) Tick() {
-	count := atomic.LoadInt64(&a.uncounted)
-	atomic.AddInt64(&a.uncounted, -count)
-	instantRate := float64(count) / float64(TickDuration)
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
+	count := atomic.SwapInt64(&a.uncounted, 0)
+	instantRate := float64(count) / float64(a.interval)
 	if a.init {
 		a.rate += a.alpha * (instantRate - a.rate)
 	} else {
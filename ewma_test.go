@@ -222,3 +222,27 @@ func elapseMinute(a EWMA) {
 		a.Tick()
 	}
 }
+
+func TestEWMAInvalidAlpha(t *testing.T) {
+	for _, alpha := range []float64{0, 1, -0.5, 2} {
+		if _, err := NewEWMA(alpha); err == nil {
+			t.Errorf("NewEWMA(%v): expected error", alpha)
+		}
+	}
+}
+
+func TestEWMAClear(t *testing.T) {
+	a := NewEWMA1()
+	a.Update(3)
+	a.Tick()
+	a.Update(5)
+	a.Clear()
+	if rate := a.Rate(); 0 != rate {
+		t.Errorf("a.Rate(): 0 != %v\n", rate)
+	}
+	a.Update(3)
+	a.Tick()
+	if rate := a.Rate(); 0.6 != rate {
+		t.Errorf("a.Rate(): 0.6 != %v\n", rate)
+	}
+}
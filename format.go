@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Percentiles included in string representations of histograms and timers.
+var formatPercentiles = []float64{0.5, 0.95, 0.99}
+
+func formatCounter(c Counter) string {
+	return fmt.Sprintf("count=%d", c.Count())
+}
+
+func formatGauge(g Gauge) string {
+	return fmt.Sprintf("value=%d", g.Value())
+}
+
+func formatMeter(m Meter) string {
+	return fmt.Sprintf("count=%d rate1=%g rate5=%g rate15=%g mean-rate=%g",
+		m.Count(), m.Rate1(), m.Rate5(), m.Rate15(), m.RateMean())
+}
+
+func formatHistogram[T Number](h TypedHistogram[T]) string {
+	ps := h.Percentiles(formatPercentiles)
+	return fmt.Sprintf("count=%d min=%v max=%v mean=%g stddev=%g p50=%g p95=%g p99=%g",
+		h.Count(), h.Min(), h.Max(), h.Mean(), h.StdDev(), ps[0], ps[1], ps[2])
+}
+
+func formatTimer(t Timer) string {
+	ps := t.Percentiles(formatPercentiles)
+	return fmt.Sprintf("count=%d min=%d max=%d mean=%g stddev=%g p50=%g p95=%g p99=%g rate1=%g rate5=%g rate15=%g mean-rate=%g",
+		t.Count(), t.Min(), t.Max(), t.Mean(), t.StdDev(), ps[0], ps[1], ps[2],
+		t.Rate1(), t.Rate5(), t.Rate15(), t.RateMean())
+}
+
+// A float64 which encodes to JSON as null when it is not finite, since JSON
+// has no representation for NaN and infinities.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(f))
+}
+
+// A value of a TypedHistogram which, like jsonFloat, encodes to JSON as null
+// when it is not finite.  Integers are encoded as they are, without a
+// conversion to float64.
+type jsonNumber[T Number] struct{ v T }
+
+func (n jsonNumber[T]) MarshalJSON() ([]byte, error) {
+	if f := float64(n.v); math.IsNaN(f) || math.IsInf(f, 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.v)
+}
+
+func marshalCounter(c Counter) ([]byte, error) {
+	return json.Marshal(struct {
+		Count int64 `json:"count"`
+	}{c.Count()})
+}
+
+func marshalGauge(g Gauge) ([]byte, error) {
+	return json.Marshal(struct {
+		Value int64 `json:"value"`
+	}{g.Value()})
+}
+
+func marshalMeter(m Meter) ([]byte, error) {
+	return json.Marshal(struct {
+		Count    int64     `json:"count"`
+		Rate1    jsonFloat `json:"rate1"`
+		Rate5    jsonFloat `json:"rate5"`
+		Rate15   jsonFloat `json:"rate15"`
+		RateMean jsonFloat `json:"mean_rate"`
+	}{m.Count(), jsonFloat(m.Rate1()), jsonFloat(m.Rate5()), jsonFloat(m.Rate15()), jsonFloat(m.RateMean())})
+}
+
+func marshalHistogram[T Number](h TypedHistogram[T]) ([]byte, error) {
+	ps := h.Percentiles(formatPercentiles)
+	return json.Marshal(struct {
+		Count  int64         `json:"count"`
+		Min    jsonNumber[T] `json:"min"`
+		Max    jsonNumber[T] `json:"max"`
+		Mean   jsonFloat     `json:"mean"`
+		StdDev jsonFloat     `json:"stddev"`
+		P50    jsonFloat     `json:"p50"`
+		P95    jsonFloat     `json:"p95"`
+		P99    jsonFloat     `json:"p99"`
+	}{h.Count(), jsonNumber[T]{h.Min()}, jsonNumber[T]{h.Max()}, jsonFloat(h.Mean()), jsonFloat(h.StdDev()),
+		jsonFloat(ps[0]), jsonFloat(ps[1]), jsonFloat(ps[2])})
+}
+
+func marshalTimer(t Timer) ([]byte, error) {
+	ps := t.Percentiles(formatPercentiles)
+	return json.Marshal(struct {
+		Count    int64     `json:"count"`
+		Min      int64     `json:"min"`
+		Max      int64     `json:"max"`
+		Mean     jsonFloat `json:"mean"`
+		StdDev   jsonFloat `json:"stddev"`
+		P50      jsonFloat `json:"p50"`
+		P95      jsonFloat `json:"p95"`
+		P99      jsonFloat `json:"p99"`
+		Rate1    jsonFloat `json:"rate1"`
+		Rate5    jsonFloat `json:"rate5"`
+		Rate15   jsonFloat `json:"rate15"`
+		RateMean jsonFloat `json:"mean_rate"`
+	}{t.Count(), t.Min(), t.Max(), jsonFloat(t.Mean()), jsonFloat(t.StdDev()),
+		jsonFloat(ps[0]), jsonFloat(ps[1]), jsonFloat(ps[2]),
+		jsonFloat(t.Rate1()), jsonFloat(t.Rate5()), jsonFloat(t.Rate15()), jsonFloat(t.RateMean())})
+}
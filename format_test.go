@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	c := NewCounter()
+	c.Inc(5)
+	cs := NewCounter()
+	cs.Inc(7)
+	g := NewGauge()
+	g.Update(-3)
+	h := MustNewHistogram(MustNewUniformSample(100))
+	for i := 1; i <= 4; i++ {
+		h.Update(int64(i))
+	}
+	now := time.Unix(0, 0)
+	m := MustNewMeter(WithClock(func() time.Time { return now }))
+	m.Mark(3)
+	m.Tick()
+	now = now.Add(time.Second)
+	for _, tc := range []struct {
+		metric   interface{}
+		expected string
+	}{
+		{c, "count=5"},
+		{cs.SnapshotAndReset(), "count=7"},
+		{g, "value=-3"},
+		{h, "count=4 min=1 max=4 mean=2.5 stddev=1.2909944487358056 p50=2.5 p95=4 p99=4"},
+		{m, "count=3 rate1=0.6 rate5=0.6 rate15=0.6 mean-rate=3"},
+	} {
+		if s := fmt.Sprint(tc.metric); tc.expected != s {
+			t.Errorf("fmt.Sprint(): %q != %q\n", tc.expected, s)
+		}
+	}
+}
+
+func TestFormatTimer(t *testing.T) {
+	tm := MustNewTimer(WithUnit(time.Millisecond))
+	tm.Update(time.Second)
+	const expected = "count=1 min=1000 max=1000 mean=1000 stddev=0 p50=1000 p95=1000 p99=1000 rate1=0 rate5=0 rate15=0"
+	if s := fmt.Sprint(tm.SnapshotAndReset()); !strings.HasPrefix(s, expected) {
+		t.Errorf("fmt.Sprint(): %q is not prefixed with %q\n", s, expected)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	c := NewCounter()
+	c.Inc(5)
+	h := MustNewHistogram(MustNewUniformSample(100))
+	for i := 1; i <= 4; i++ {
+		h.Update(int64(i))
+	}
+	now := time.Unix(0, 0)
+	m := MustNewMeter(WithClock(func() time.Time { return now }))
+	for _, tc := range []struct {
+		metric   interface{}
+		expected string
+	}{
+		{c, `{"count":5}`},
+		{h.SnapshotAndReset(), `{"count":4,"min":1,"max":4,"mean":2.5,"stddev":1.2909944487358056,"p50":2.5,"p95":4,"p99":4}`},
+		{m, `{"count":0,"rate1":0,"rate5":0,"rate15":0,"mean_rate":null}`},
+		{map[string]interface{}{"requests": c}, `{"requests":{"count":5}}`},
+	} {
+		b, err := json.Marshal(tc.metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.expected != string(b) {
+			t.Errorf("json.Marshal(): %s != %s\n", tc.expected, b)
+		}
+	}
+}
+
+func TestMarshalJSONHistogramBounds(t *testing.T) {
+	f := MustNewTypedHistogram[float64](nil)
+	f.Update(math.Inf(-1))
+	f.Update(math.Inf(1))
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"min":null,"max":null`) {
+		t.Errorf("json.Marshal(): no null min and max in %s\n", b)
+	}
+	h := MustNewHistogram(nil)
+	h.Update(math.MaxInt64)
+	if b, err = json.Marshal(h); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"max":9223372036854775807`) {
+		t.Errorf("json.Marshal(): no exact max in %s\n", b)
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	g := NewGauge()
+	g.Update(2)
+	var m encoding.TextMarshaler = g.(encoding.TextMarshaler)
+	b, err := m.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if "value=2" != string(b) {
+		t.Errorf("g.MarshalText(): value=2 != %s\n", b)
+	}
+}
@@ -1,9 +1,19 @@
 package metrics
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // Gauges hold an int64 value that can be set arbitrarily.
 type Gauge interface {
+	// Return the time the gauge was created.
+	Created() time.Time
+
+	// Return the time the gauge was last updated, or the zero time if it
+	// never was or was created without WithLastUpdate.
+	LastUpdate() time.Time
+
 	// Update the gauge's value.
 	Update(value int64)
 
@@ -15,17 +25,29 @@ type Gauge interface {
 // to manage a single int64 value.
 type gauge struct {
 	value int64
+	timestamps
 }
 
-// Create a new gauge.
-func NewGauge() Gauge {
-	return &gauge{0}
+// Create a new gauge.  It honors the WithClock and WithLastUpdate options;
+// a nil clock means time.Now.
+func NewGauge(opts ...Option) Gauge {
+	o := newOptions(opts)
+	g := &gauge{}
+	g.init(&o)
+	return g
 }
 
 func (g *gauge) Update(v int64) {
 	atomic.StoreInt64(&g.value, v)
+	g.touch()
 }
 
 func (g *gauge) Value() int64 {
 	return atomic.LoadInt64(&g.value)
 }
+
+func (g *gauge) String() string { return formatGauge(g) }
+
+func (g *gauge) MarshalJSON() ([]byte, error) { return marshalGauge(g) }
+
+func (g *gauge) MarshalText() ([]byte, error) { return []byte(formatGauge(g)), nil }
@@ -9,3 +9,19 @@ func TestGauge(t *testing.T) {
 		t.Errorf("g.Value(): 47 != %v\n", v)
 	}
 }
+
+func TestGaugeLastUpdate(t *testing.T) {
+	g := NewGauge(WithLastUpdate())
+	if ts := g.LastUpdate(); !ts.IsZero() {
+		t.Errorf("g.LastUpdate(): zero time != %v\n", ts)
+	}
+	g.Update(1)
+	if ts := g.LastUpdate(); ts.IsZero() {
+		t.Error("g.LastUpdate(): zero time after g.Update()")
+	}
+	g = NewGauge()
+	g.Update(1)
+	if ts := g.LastUpdate(); !ts.IsZero() {
+		t.Errorf("g.LastUpdate() without WithLastUpdate: zero time != %v\n", ts)
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Guards run functions, typically goroutine entry points, counting the
+// panics they recover and the errors returned by type.
+type Guard interface {
+	// Return the number of errors returned by guarded functions, keyed by the
+	// errors' dynamic type as printed by the %T verb.
+	Errors() map[string]int64
+
+	// Return the number of panics recovered from guarded functions.
+	Panics() int64
+
+	// Run f and return its error.  A panic in f is counted and, unless the
+	// guard re-panics, converted to an error.  It should be used like:
+	//
+	//     go g.Run(worker)
+	Run(f func() error) error
+}
+
+// The standard implementation of a Guard uses a Counter for panics and a
+// mutex-protected map of Counters for errors.
+type guard struct {
+	repanic bool
+	panics  Counter
+	mutex   sync.Mutex
+	errors  map[string]Counter
+}
+
+// Create a new guard.  If repanic is true, recovered panics are counted and
+// then resumed.
+func NewGuard(repanic bool) Guard {
+	return &guard{
+		repanic: repanic,
+		panics:  NewCounter(),
+		errors:  make(map[string]Counter),
+	}
+}
+
+func (g *guard) Errors() map[string]int64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	errors := make(map[string]int64, len(g.errors))
+	for k, c := range g.errors {
+		errors[k] = c.Count()
+	}
+	return errors
+}
+
+func (g *guard) Panics() int64 {
+	return g.panics.Count()
+}
+
+func (g *guard) Run(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.panics.Inc(1)
+			if g.repanic {
+				panic(r)
+			}
+			err = fmt.Errorf("metrics: recovered panic: %v", r)
+		}
+	}()
+	if err = f(); err != nil {
+		g.countError(err)
+	}
+	return err
+}
+
+func (g *guard) countError(err error) {
+	k := fmt.Sprintf("%T", err)
+	g.mutex.Lock()
+	c, ok := g.errors[k]
+	if !ok {
+		c = NewCounter()
+		g.errors[k] = c
+	}
+	g.mutex.Unlock()
+	c.Inc(1)
+}
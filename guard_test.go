@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestGuard(t *testing.T) {
+	g := NewGuard(false)
+	if err := g.Run(func() error { return nil }); err != nil {
+		t.Errorf("g.Run(): nil != %v\n", err)
+	}
+	g.Run(func() error { return errors.New("boom") })
+	g.Run(func() error { return errors.New("boom") })
+	g.Run(func() error { return &os.PathError{} })
+	if err := g.Run(func() error { panic("boom") }); err == nil {
+		t.Error("g.Run() with panic: expected error")
+	}
+	if n := g.Panics(); 1 != n {
+		t.Errorf("g.Panics(): 1 != %v\n", n)
+	}
+	errs := g.Errors()
+	if n := errs["*errors.errorString"]; 2 != n {
+		t.Errorf("g.Errors()[\"*errors.errorString\"]: 2 != %v\n", n)
+	}
+	if n := errs["*fs.PathError"]; 1 != n {
+		t.Errorf("g.Errors()[\"*fs.PathError\"]: 1 != %v\n", n)
+	}
+}
+
+func TestGuardRepanic(t *testing.T) {
+	g := NewGuard(true)
+	defer func() {
+		if recover() == nil {
+			t.Error("g.Run(): expected panic")
+		}
+		if n := g.Panics(); 1 != n {
+			t.Errorf("g.Panics(): 1 != %v\n", n)
+		}
+	}()
+	g.Run(func() error { panic("boom") })
+}
@@ -2,26 +2,45 @@ package metrics
 
 import (
 	"math"
-	"sort"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Histograms calculate distribution statistics from an int64 value.
-type Histogram interface {
+type Histogram = TypedHistogram[int64]
+
+// TypedHistograms calculate distribution statistics from values of an
+// arbitrary numeric type.
+type TypedHistogram[T Number] interface {
 	// Clear the histogram.
 	Clear()
 
 	// Return the count of inputs since the histogram was last cleared.
 	Count() int64
 
+	// Return the time the histogram was created.
+	Created() time.Time
+
+	// Return the time the histogram was last updated, or the zero time if it
+	// never was or was created without WithLastUpdate.
+	LastUpdate() time.Time
+
 	// Return the maximal value seen since the histogram was last cleared.
-	Max() int64
+	Max() T
 
 	// Return the mean of all values seen since the histogram was last cleared.
 	Mean() float64
 
 	// Return the minimal value seen since the histogram was last cleared.
-	Min() int64
+	Min() T
+
+	// Return the last values the histogram was updated with, oldest first, or
+	// nil unless its sample keeps them.  See WithRecent.
+	Recent() []T
 
 	// Return an arbitrary percentile of all values seen since the histogram was
 	// last cleared.
@@ -31,137 +50,278 @@ type Histogram interface {
 	// histogram was last cleared.
 	Percentiles(ps []float64) []float64
 
+	// Return the fraction of values seen since the histogram was last cleared
+	// which are less than or equal to the given value.
+	PercentileRank(value T) float64
+
+	// Atomically return the current state of the histogram as a read-only
+	// histogram and clear it.  Calling Clear, Update or SnapshotAndReset on
+	// the returned histogram panics.
+	SnapshotAndReset() TypedHistogram[T]
+
 	// Return the standard deviation of all values seen since the histogram was
 	// last cleared.
 	StdDev() float64
 
 	// Update the histogram with a new value.
-	Update(value int64)
+	Update(value T)
+
+	// Update the histogram with several new values at once.
+	UpdateBatch(values []T)
 
 	// Return the variance of all values seen since the histogram was last cleared.
 	Variance() float64
 }
 
-// The standard implementation of a Histogram uses a Sample and a goroutine
-// to synchronize its calculations.
-type histogram struct {
-	count, sum, min, max int64
-	mutex                sync.Mutex
-	s                    Sample
-	variance             [2]float64
+// The standard implementation of a Histogram uses a Sample for percentiles
+// and keeps count, sum, min, max and a running variance of all values
+// itself.
+type histogram[T Number] struct {
+	count    int64
+	sum      sum[T]
+	min, max T
+	mutex    sync.Mutex
+	s        TypedSample[T]
+	variance [2]float64
+	now      func() time.Time
+	timestamps
+
+	// Adaptive sampling, enabled by WithAdaptiveSampling.  Calls are counted
+	// over windows starting at an offset from epoch, outside of the mutex.
+	sampleN     int64
+	sampleRate  float64
+	epoch       time.Time
+	windowStart atomic.Int64
+	calls       atomic.Int64
+	sampling    atomic.Bool
+}
+
+// Create a new histogram with the given Sample.  See NewTypedHistogram for
+// the options it honors.  A nil sample makes a histogram which does not keep
+// values, whose percentiles are always zero.
+func NewHistogram(s Sample, opts ...Option) (Histogram, error) {
+	return NewTypedHistogram[int64](s, opts...)
 }
 
-// Create a new histogram with the given Sample.  The initial values compare
-// so that the first value will be both min and max and the variance is flagged
-// for special treatment on its first iteration.
-func NewHistogram(s Sample) Histogram {
-	return &histogram{
-		max:      math.MinInt64,
-		min:      math.MaxInt64,
-		s:        s,
-		variance: [2]float64{-1.0, 0.0},
+// Like NewHistogram but panics if the options are invalid.
+func MustNewHistogram(s Sample, opts ...Option) Histogram {
+	return MustNewTypedHistogram[int64](s, opts...)
+}
+
+// Create a new histogram of values of type T with the given TypedSample.
+// It honors the WithAdaptiveSampling, WithClock and WithLastUpdate
+// options.  Options which configure samples, meters or timers, such as
+// WithReservoirSize or WithUnit, are ignored, so that a Timer can pass its
+// options on as they are.
+func NewTypedHistogram[T Number](s TypedSample[T], opts ...Option) (TypedHistogram[T], error) {
+	o := newOptions(opts)
+	if err := checkClock(o.now); err != nil {
+		return nil, err
+	}
+	h := &histogram[T]{s: s, now: o.now}
+	h.init(&o)
+	h.epoch = h.created
+	if o.sampleN > 1 {
+		h.sampleN = int64(o.sampleN)
+		h.sampleRate = o.sampleRate
 	}
+	return h, nil
 }
 
-func (h *histogram) Clear() {
+// Like NewTypedHistogram but panics if the options are invalid.
+func MustNewTypedHistogram[T Number](s TypedSample[T], opts ...Option) TypedHistogram[T] {
+	h, err := NewTypedHistogram[T](s, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func (h *histogram[T]) Clear() {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
+	h.clear()
+}
+
+// Reset the histogram; must be called with the mutex held.
+func (h *histogram[T]) clear() {
 	h.count = 0
-	h.max = math.MinInt64
-	h.min = math.MaxInt64
-	h.s.Clear()
-	h.sum = 0
-	h.variance = [...]float64{-1.0, 0.0}
+	h.max = 0
+	h.min = 0
+	if h.s != nil {
+		h.s.Clear()
+	}
+	h.sum = sum[T]{}
+	h.variance = [...]float64{0.0, 0.0}
 }
 
-func (h *histogram) Count() int64 {
+func (h *histogram[T]) Count() int64 {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 	return h.count
 }
 
-func (h *histogram) Max() int64 {
+func (h *histogram[T]) Max() T {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	if 0 == h.count {
-		return 0
-	}
 	return h.max
 }
 
-func (h *histogram) Mean() float64 {
+func (h *histogram[T]) Mean() float64 {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 	if 0 == h.count {
 		return 0
 	}
-	return float64(h.sum) / float64(h.count)
+	return h.sum.value() / float64(h.count)
 }
 
-func (h *histogram) Min() int64 {
+func (h *histogram[T]) Min() T {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	if 0 == h.count {
-		return 0
-	}
 	return h.min
 }
 
-func (h *histogram) Percentile(p float64) float64 {
+func (h *histogram[T]) Percentile(p float64) float64 {
 	return h.Percentiles([]float64{p})[0]
 }
 
-func (h *histogram) Percentiles(ps []float64) []float64 {
-	scores := make([]float64, len(ps))
-	values := int64Slice(h.s.Values())
-	size := len(values)
-	if size > 0 {
-		sort.Sort(values)
-		for i, p := range ps {
-			pos := p * float64(size+1)
-			if pos < 1.0 {
-				scores[i] = float64(values[0])
-			} else if pos >= float64(size) {
-				scores[i] = float64(values[size-1])
-			} else {
-				lower := float64(values[int(pos)-1])
-				upper := float64(values[int(pos)])
-				scores[i] = lower + (pos-math.Floor(pos))*(upper-lower)
-			}
-		}
+func (h *histogram[T]) Percentiles(ps []float64) []float64 {
+	values := h.values()
+	slices.Sort(values)
+	return percentiles(values, ps)
+}
+
+func (h *histogram[T]) PercentileRank(v T) float64 {
+	return percentileRank(h.values(), v)
+}
+
+func (h *histogram[T]) Recent() []T {
+	if h.s == nil {
+		return nil
 	}
-	return scores
+	return h.s.Recent()
 }
 
-func (h *histogram) StdDev() float64 {
+func (h *histogram[T]) SnapshotAndReset() TypedHistogram[T] {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	values := h.values()
+	slices.Sort(values)
+	s := &histogramSnapshot[T]{
+		count:    h.count,
+		sum:      h.sum,
+		min:      h.min,
+		max:      h.max,
+		values:   values,
+		recent:   h.Recent(),
+		variance: h.variance,
+	}
+	s.frozenTimestamps = h.snapshot()
+	h.clear()
+	return s
+}
+
+func (h *histogram[T]) StdDev() float64 {
 	return math.Sqrt(h.Variance())
 }
 
-func (h *histogram) Update(v int64) {
+func (h *histogram[T]) Update(v T) {
+	h.touch()
+	w := int64(1)
+	if h.sampleN > 1 {
+		if w = h.weight(h.now()); 0 == w {
+			return
+		}
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.s != nil {
+		h.s.Update(v)
+	}
+	h.record(v, w)
+}
+
+func (h *histogram[T]) UpdateBatch(vs []T) {
+	h.touch()
+	ws := make([]int64, len(vs))
+	kept := vs
+	if h.sampleN > 1 {
+		now := h.now()
+		kept = make([]T, 0, len(vs))
+		for _, v := range vs {
+			if w := h.weight(now); 0 != w {
+				ws[len(kept)] = w
+				kept = append(kept, v)
+			}
+		}
+	} else {
+		for i := range ws {
+			ws[i] = 1
+		}
+	}
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	h.s.Update(v)
-	h.count++
-	if v < h.min {
+	if h.s != nil {
+		h.s.UpdateBatch(kept)
+	}
+	for i, v := range kept {
+		h.record(v, ws[i])
+	}
+}
+
+// Return the values in the sample, if there is one.
+func (h *histogram[T]) values() []T {
+	if h.s == nil {
+		return nil
+	}
+	return h.s.Values()
+}
+
+// Record v in the statistics as if it was seen w times; must be called with
+// the mutex held.
+func (h *histogram[T]) record(v T, w int64) {
+	first := 0 == h.count
+	h.count += w
+	if first || v < h.min {
 		h.min = v
 	}
-	if v > h.max {
+	if first || v > h.max {
 		h.max = v
 	}
-	h.sum += v
+	h.sum.add(v, w)
 	fv := float64(v)
-	if -1.0 == h.variance[0] {
+	if first {
 		h.variance[0] = fv
 		h.variance[1] = 0.0
 	} else {
 		m := h.variance[0]
-		s := h.variance[1]
-		h.variance[0] = m + (fv-m)/float64(h.count)
-		h.variance[1] = s + (fv-m)*(fv-h.variance[0])
+		h.variance[0] = m + (fv-m)*float64(w)/float64(h.count)
+		h.variance[1] += float64(w) * (fv - m) * (fv - h.variance[0])
 	}
 }
 
-func (h *histogram) Variance() float64 {
+// Count an update call made at t for adaptive sampling and return the
+// weight it should be recorded with, or 0 if it should be skipped.
+func (h *histogram[T]) weight(t time.Time) int64 {
+	h.calls.Add(1)
+	now := int64(t.Sub(h.epoch))
+	start := h.windowStart.Load()
+	if elapsed := now - start; elapsed >= int64(time.Second) &&
+		h.windowStart.CompareAndSwap(start, now) {
+		rate := float64(h.calls.Swap(0)) / time.Duration(elapsed).Seconds()
+		h.sampling.Store(rate > h.sampleRate)
+	}
+	if !h.sampling.Load() {
+		return 1
+	}
+	if 0 != rand.Int64N(h.sampleN) {
+		return 0
+	}
+	return h.sampleN
+}
+
+func (h *histogram[T]) Variance() float64 {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 	if 1 >= h.count {
@@ -170,9 +330,163 @@ func (h *histogram) Variance() float64 {
 	return h.variance[1] / float64(h.count-1)
 }
 
-// Cribbed from the standard library's `sort` package.
-type int64Slice []int64
+// A read-only copy of a histogram's state.
+type histogramSnapshot[T Number] struct {
+	count    int64
+	sum      sum[T]
+	min, max T
+	values   []T // sorted
+	recent   []T
+	variance [2]float64
+	frozenTimestamps
+}
+
+func (h *histogramSnapshot[T]) Clear() {
+	panic("metrics: Clear called on a histogram snapshot")
+}
+
+func (h *histogramSnapshot[T]) Count() int64 { return h.count }
+
+func (h *histogramSnapshot[T]) Max() T { return h.max }
+
+func (h *histogramSnapshot[T]) Mean() float64 {
+	if 0 == h.count {
+		return 0
+	}
+	return h.sum.value() / float64(h.count)
+}
+
+func (h *histogramSnapshot[T]) Min() T { return h.min }
+
+func (h *histogramSnapshot[T]) Percentile(p float64) float64 {
+	return h.Percentiles([]float64{p})[0]
+}
+
+func (h *histogramSnapshot[T]) Percentiles(ps []float64) []float64 {
+	return percentiles(h.values, ps)
+}
+
+func (h *histogramSnapshot[T]) PercentileRank(v T) float64 {
+	return percentileRank(h.values, v)
+}
+
+func (h *histogramSnapshot[T]) Recent() []T { return h.recent }
+
+func (h *histogramSnapshot[T]) SnapshotAndReset() TypedHistogram[T] {
+	panic("metrics: SnapshotAndReset called on a histogram snapshot")
+}
+
+func (h *histogramSnapshot[T]) StdDev() float64 {
+	return math.Sqrt(h.Variance())
+}
+
+func (h *histogramSnapshot[T]) Update(T) {
+	panic("metrics: Update called on a histogram snapshot")
+}
+
+func (h *histogramSnapshot[T]) UpdateBatch([]T) {
+	panic("metrics: UpdateBatch called on a histogram snapshot")
+}
+
+func (h *histogramSnapshot[T]) Variance() float64 {
+	if 1 >= h.count {
+		return 0.0
+	}
+	return h.variance[1] / float64(h.count-1)
+}
+
+// A running sum of values of type T, kept in an int64 for signed and in 128
+// bits for unsigned integer types so that it stays exact past 2^53, which
+// nanosecond timers reach, and past 2^64 for large unsigned values.
+type sum[T Number] struct {
+	i      int64
+	hi, lo uint64
+	f      float64
+}
+
+// Add v, seen w times.
+func (s *sum[T]) add(v T, w int64) {
+	switch {
+	case isUnsigned[T]():
+		hi, lo := bits.Mul64(uint64(v), uint64(w))
+		var carry uint64
+		s.lo, carry = bits.Add64(s.lo, lo, 0)
+		s.hi += hi + carry
+	case isInteger[T]():
+		s.i += int64(v) * w
+	default:
+		s.f += float64(v) * float64(w)
+	}
+}
+
+func (s *sum[T]) value() float64 {
+	switch {
+	case isUnsigned[T]():
+		return float64(s.hi)*(1<<64) + float64(s.lo)
+	case isInteger[T]():
+		return float64(s.i)
+	}
+	return s.f
+}
+
+// Report whether T is an integer type, in which division truncates.
+func isInteger[T Number]() bool {
+	var one T = 1
+	return 0 == one/2
+}
+
+// Report whether T is an unsigned integer type, in which subtraction wraps
+// around at zero.
+func isUnsigned[T Number]() bool {
+	var zero T
+	return zero-1 > zero
+}
+
+// Return the given percentiles of values, which must be sorted.
+func percentiles[T Number](values []T, ps []float64) []float64 {
+	scores := make([]float64, len(ps))
+	size := len(values)
+	if size > 0 {
+		for i, p := range ps {
+			pos := p * float64(size+1)
+			if pos < 1.0 {
+				scores[i] = float64(values[0])
+			} else if pos >= float64(size) {
+				scores[i] = float64(values[size-1])
+			} else {
+				lower := float64(values[int(pos)-1])
+				upper := float64(values[int(pos)])
+				scores[i] = lower + (pos-math.Floor(pos))*(upper-lower)
+			}
+		}
+	}
+	return scores
+}
+
+// Return the fraction of values less than or equal to v.
+func percentileRank[T Number](values []T, v T) float64 {
+	if 0 == len(values) {
+		return 0
+	}
+	n := 0
+	for _, x := range values {
+		if x <= v {
+			n++
+		}
+	}
+	return float64(n) / float64(len(values))
+}
+
+func (h *histogram[T]) String() string { return formatHistogram[T](h) }
+
+func (h *histogramSnapshot[T]) String() string { return formatHistogram[T](h) }
 
-func (p int64Slice) Len() int           { return len(p) }
-func (p int64Slice) Less(i, j int) bool { return p[i] < p[j] }
-func (p int64Slice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (h *histogram[T]) MarshalJSON() ([]byte, error) { return marshalHistogram[T](h) }
+
+func (h *histogram[T]) MarshalText() ([]byte, error) { return []byte(formatHistogram[T](h)), nil }
+
+func (h *histogramSnapshot[T]) MarshalJSON() ([]byte, error) { return marshalHistogram[T](h) }
+
+func (h *histogramSnapshot[T]) MarshalText() ([]byte, error) {
+	return []byte(formatHistogram[T](h)), nil
+}
@@ -1,16 +1,20 @@
 package metrics
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"time"
+)
 
 func BenchmarkHistogram(b *testing.B) {
-	h := NewHistogram(NewUniformSample(100))
+	h := MustNewHistogram(MustNewUniformSample(100))
 	for i := 0; i < b.N; i++ {
 		h.Update(1)
 	}
 }
 
 func TestEmptyHistogram(t *testing.T) {
-	h := NewHistogram(NewUniformSample(100))
+	h := MustNewHistogram(MustNewUniformSample(100))
 	if count := h.Count(); 0 != count {
 		t.Errorf("h.Count(): 0 != %v\n", count)
 	}
@@ -39,7 +43,7 @@ func TestEmptyHistogram(t *testing.T) {
 }
 
 func TestHistogram10000(t *testing.T) {
-	h := NewHistogram(NewUniformSample(100000))
+	h := MustNewHistogram(MustNewUniformSample(100000))
 	for i := 1; i <= 10000; i++ {
 		h.Update(int64(i))
 	}
@@ -69,3 +73,215 @@ func TestHistogram10000(t *testing.T) {
 		t.Errorf("99th percentile: 9900.99 != %v\n", ps[2])
 	}
 }
+
+func TestHistogramStatsIndependentOfSample(t *testing.T) {
+	s := MustNewUniformSample(2)
+	h := MustNewHistogram(s)
+	for i := 1; i <= 10000; i++ {
+		h.Update(int64(i))
+	}
+	if size := s.Size(); 2 != size {
+		t.Errorf("s.Size(): 2 != %v\n", size)
+	}
+	if mean := h.Mean(); 5000.5 != mean {
+		t.Errorf("h.Mean(): 5000.5 != %v\n", mean)
+	}
+	if stdDev := h.StdDev(); 2886.8956799071675 != stdDev {
+		t.Errorf("h.StdDev(): 2886.8956799071675 != %v\n", stdDev)
+	}
+}
+
+func TestHistogramMinMaxIndependentOfSample(t *testing.T) {
+	h := MustNewHistogram(MustNewUniformSample(1))
+	h.Update(1000)
+	for i := 0; i < 1000; i++ {
+		h.Update(500)
+	}
+	h.Update(-1)
+	for i := 0; i < 1000; i++ {
+		h.Update(500)
+	}
+	if max := h.Max(); 1000 != max {
+		t.Errorf("h.Max(): 1000 != %v\n", max)
+	}
+	if min := h.Min(); -1 != min {
+		t.Errorf("h.Min(): -1 != %v\n", min)
+	}
+	s := h.SnapshotAndReset()
+	if max := s.Max(); 1000 != max {
+		t.Errorf("s.Max(): 1000 != %v\n", max)
+	}
+	h.Update(7)
+	if max := h.Max(); 7 != max {
+		t.Errorf("h.Max(): 7 != %v\n", max)
+	}
+}
+
+func TestHistogramExactSum(t *testing.T) {
+	h := MustNewHistogram(MustNewUniformSample(100))
+	h.Update(1 << 53)
+	h.Update(1)
+	h.Update(1)
+	if mean, expected := h.Mean(), float64(1<<53+2)/3; expected != mean {
+		t.Errorf("h.Mean(): %v != %v\n", expected, mean)
+	}
+}
+
+func TestHistogramExactSumUnsigned(t *testing.T) {
+	h := MustNewTypedHistogram[uint64](nil)
+	h.Update(1 << 63)
+	h.Update(1 << 63)
+	if mean, expected := h.Mean(), float64(1<<63); expected != mean {
+		t.Errorf("h.Mean(): %v != %v\n", expected, mean)
+	}
+	if max := h.Max(); 1<<63 != max {
+		t.Errorf("h.Max(): %v != %v\n", uint64(1<<63), max)
+	}
+}
+
+func TestTypedHistogramFloat64(t *testing.T) {
+	s, err := NewTypedUniformSample[float64](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := MustNewTypedHistogram(s)
+	for _, v := range []float64{0.5, 1.5, -2.5} {
+		h.Update(v)
+	}
+	if min := h.Min(); -2.5 != min {
+		t.Errorf("h.Min(): -2.5 != %v\n", min)
+	}
+	if max := h.Max(); 1.5 != max {
+		t.Errorf("h.Max(): 1.5 != %v\n", max)
+	}
+	if mean := h.Mean(); -0.5/3 != mean {
+		t.Errorf("h.Mean(): %v != %v\n", -0.5/3, mean)
+	}
+	if median := h.Percentile(0.5); 0.5 != median {
+		t.Errorf("median: 0.5 != %v\n", median)
+	}
+}
+
+func TestTypedHistogramUint8(t *testing.T) {
+	s, err := NewTypedExpDecaySample[uint8](100, 0.015)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := MustNewTypedHistogram(s)
+	for i := 0; i < 10; i++ {
+		h.Update(250)
+	}
+	if count := h.Count(); 10 != count {
+		t.Errorf("h.Count(): 10 != %v\n", count)
+	}
+	if mean := h.Mean(); 250 != mean {
+		t.Errorf("h.Mean(): 250 != %v\n", mean)
+	}
+}
+
+func TestHistogramSnapshotAndReset(t *testing.T) {
+	h := MustNewHistogram(MustNewUniformSample(100))
+	for i := 1; i <= 100; i++ {
+		h.Update(int64(i))
+	}
+	s := h.SnapshotAndReset()
+	if count := h.Count(); 0 != count {
+		t.Errorf("h.Count(): 0 != %v\n", count)
+	}
+	if count := s.Count(); 100 != count {
+		t.Errorf("s.Count(): 100 != %v\n", count)
+	}
+	if max := s.Max(); 100 != max {
+		t.Errorf("s.Max(): 100 != %v\n", max)
+	}
+	if mean := s.Mean(); 50.5 != mean {
+		t.Errorf("s.Mean(): 50.5 != %v\n", mean)
+	}
+	if median := s.Percentile(0.5); 50.5 != median {
+		t.Errorf("median: 50.5 != %v\n", median)
+	}
+	h.Update(7)
+	if max := s.Max(); 100 != max {
+		t.Errorf("s.Max() after h.Update(): 100 != %v\n", max)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("s.Update(): expected panic")
+		}
+	}()
+	s.Update(1)
+}
+
+func TestHistogramPercentileRank(t *testing.T) {
+	h := MustNewHistogram(MustNewUniformSample(100))
+	if r := h.PercentileRank(10); 0 != r {
+		t.Errorf("empty h.PercentileRank(10): 0 != %v\n", r)
+	}
+	for i := 1; i <= 100; i++ {
+		h.Update(int64(i))
+	}
+	if r := h.PercentileRank(25); 0.25 != r {
+		t.Errorf("h.PercentileRank(25): 0.25 != %v\n", r)
+	}
+	if r := h.PercentileRank(0); 0 != r {
+		t.Errorf("h.PercentileRank(0): 0 != %v\n", r)
+	}
+	if r := h.PercentileRank(1000); 1 != r {
+		t.Errorf("h.PercentileRank(1000): 1 != %v\n", r)
+	}
+	if r := h.SnapshotAndReset().PercentileRank(50); 0.5 != r {
+		t.Errorf("snapshot PercentileRank(50): 0.5 != %v\n", r)
+	}
+}
+
+func TestHistogramAdaptiveSampling(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := MustNewHistogram(
+		MustNewUniformSample(100),
+		WithAdaptiveSampling(100, 10),
+		WithClock(func() time.Time { return now }),
+	)
+	for i := 0; i < 50; i++ {
+		h.Update(3)
+	}
+	if count := h.Count(); 50 != count {
+		t.Errorf("h.Count() below threshold: 50 != %v\n", count)
+	}
+	for i := 0; i < 1000; i++ {
+		h.Update(3)
+	}
+	now = now.Add(time.Second)
+	h.Clear()
+	for i := 0; i < 10000; i++ {
+		h.Update(3)
+	}
+	if count := h.Count(); count < 8000 || count > 12000 || 0 != count%10 {
+		t.Errorf("h.Count() above threshold: %v is not a multiple of 10 near 10000\n", count)
+	}
+	if mean := h.Mean(); 3 != mean {
+		t.Errorf("h.Mean(): 3 != %v\n", mean)
+	}
+	if size := len(h.Percentiles([]float64{0.5})); 1 != size {
+		t.Errorf("len(h.Percentiles()): 1 != %v\n", size)
+	}
+}
+
+func TestHistogramUpdateBatch(t *testing.T) {
+	h := MustNewHistogram(MustNewUniformSample(100))
+	h.UpdateBatch([]int64{1, 2, 3, 4})
+	if count := h.Count(); 4 != count {
+		t.Errorf("h.Count(): 4 != %v\n", count)
+	}
+	if mean := h.Mean(); 2.5 != mean {
+		t.Errorf("h.Mean(): 2.5 != %v\n", mean)
+	}
+	if max := h.Max(); 4 != max {
+		t.Errorf("h.Max(): 4 != %v\n", max)
+	}
+}
+
+func TestHistogramInvalidClock(t *testing.T) {
+	if _, err := NewHistogram(MustNewUniformSample(100), WithClock(nil)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewHistogram(s, WithClock(nil)): %v is not ErrInvalidArgument", err)
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import "maps"
+
+// Infos hold a constant set of string labels describing static facts, such
+// as region or instance type.  Following the Prometheus convention they
+// behave as a gauge whose value is always 1, with the facts carried as
+// labels.
+type Info interface {
+	// Return a copy of the info's labels.
+	Labels() map[string]string
+
+	// Return the info's value, which is always 1.
+	Value() int64
+}
+
+// The standard implementation of an Info keeps its own copy of the labels,
+// which never change.
+type info struct {
+	labels map[string]string
+}
+
+// Create a new info with a copy of the given labels.
+func NewInfo(labels map[string]string) Info {
+	return &info{labels: maps.Clone(labels)}
+}
+
+func (i *info) Labels() map[string]string {
+	return maps.Clone(i.labels)
+}
+
+func (i *info) Value() int64 {
+	return 1
+}
@@ -0,0 +1,20 @@
+package metrics
+
+import "testing"
+
+func TestInfo(t *testing.T) {
+	labels := map[string]string{"region": "eu-west-1"}
+	i := NewInfo(labels)
+	labels["region"] = "us-east-1"
+	if v := i.Value(); 1 != v {
+		t.Errorf("i.Value(): 1 != %v\n", v)
+	}
+	l := i.Labels()
+	if r := l["region"]; "eu-west-1" != r {
+		t.Errorf("i.Labels()[\"region\"]: eu-west-1 != %v\n", r)
+	}
+	l["region"] = "us-east-1"
+	if r := i.Labels()["region"]; "eu-west-1" != r {
+		t.Errorf("i.Labels()[\"region\"]: eu-west-1 != %v\n", r)
+	}
+}
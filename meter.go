@@ -2,22 +2,50 @@ package metrics
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Meters count events to produce exponentially-weighted moving average rates
 // at one-, five-, and fifteen-minutes and a mean rate.
 type Meter interface {
+	// Clear the meter: set its count and rates to zero and restart the mean
+	// rate calculation.
+	Clear()
+
 	// Return the count of events seen.
 	Count() int64
 
+	// Return the time the meter was created.
+	Created() time.Time
+
+	// Return the histogram of nanoseconds elapsed between consecutive Mark
+	// calls, or nil unless the meter was created with WithInterArrival.
+	InterArrival() Histogram
+
+	// Return the time of the last Mark, or the zero time if there was none or
+	// the meter was created without WithLastUpdate.
+	LastUpdate() time.Time
+
 	// Mark the occurance of n events.
 	Mark(n int64)
 
+	// Mark the occurance of an event at each of the given times, which should
+	// be in ascending order and not earlier than previous marks.
+	MarkBatch(ts []time.Time)
+
+	// Pause the meter: ignore marks and ticks until Resume, and leave the
+	// time until then out of the mean rate.
+	Pause()
+
+	// Resume a paused meter.
+	Resume()
+
 	// Tick the clock to update the moving average.
 	Tick()
 
-	// Return the meter's one-minute moving average rate of events.
+	// Return the meter's one-minute moving average rate of events.  Rates are
+	// per second unless the meter was created with WithRateUnit.
 	Rate1() float64
 
 	// Return the meter's five-minute moving average rate of events.
@@ -28,71 +56,330 @@ type Meter interface {
 
 	// Return the meter's mean rate of events.
 	RateMean() float64
+
+	// Atomically return the meter's count and rates as a read-only meter and
+	// clear it.  Calling Clear, Mark, Pause, Resume, Tick or SnapshotAndReset
+	// on the returned meter panics.
+	SnapshotAndReset() Meter
 }
 
-// Create a new meter.
+// The standard implementation of a Meter keeps its count with the
+// sync/atomic package and relies on EWMAs being safe for concurrent use, so
+// Mark never blocks and never allocates.  Clear and SnapshotAndReset swap in
+// a fresh state instead of resetting the current one.
 type meter struct {
-	mutex  sync.RWMutex
-	count  int64
-	rate1  EWMA
-	rate5  EWMA
-	rate15 EWMA
-	start  time.Time
+	state  atomic.Pointer[meterState]
+	now    func() time.Time
+	scale  float64 // rate unit in seconds
+	ticks  time.Duration
+	paused atomic.Bool
+	timestamps
+
+	// The mean rate is measured from start, leaving out pausedFor, how long
+	// the meter was paused since, and the current pause, which began at
+	// pausedAt.  The mutex also serializes swaps of the state.  Marks only
+	// read paused, so they do not take it.
+	mutex     sync.Mutex
+	start     time.Time
+	pausedAt  time.Time
+	pausedFor time.Duration
+
+	// The state SnapshotAndReset last swapped out.  Marks which loaded it
+	// just before the swap may still add to it, so the next swap adds what
+	// they left to its own snapshot.
+	retired *meterState
+
+	// Inter-arrival tracking, enabled by WithInterArrival.  Marks read the
+	// clock with gapsMutex held so that racing marks cannot record negative
+	// gaps.
+	gaps      Histogram
+	gapsMutex sync.Mutex
+	last      time.Time
+	marked    bool // whether last is set
+}
+
+// Create a new meter.  It honors the WithClock, WithInterArrival,
+// WithLastUpdate, WithRateUnit and WithTickInterval options.
+func NewMeter(opts ...Option) (Meter, error) {
+	o := newOptions(opts)
+	if err := firstError(
+		checkClock(o.now),
+		checkRateUnit(o.rateUnit),
+		checkTickInterval(o.tickInterval),
+	); err != nil {
+		return nil, err
+	}
+	m := &meter{
+		now:   o.now,
+		scale: o.rateUnit.Seconds(),
+		ticks: o.tickInterval,
+	}
+	m.state.Store(m.newState())
+	if o.interArrival != nil {
+		s := o.interArrival()
+		if nil == s {
+			return nil, invalidf("inter-arrival sample must not be nil")
+		}
+		m.gaps = MustNewHistogram(s)
+	}
+	m.init(&o)
+	m.start = m.created
+	return m, nil
+}
+
+// Like NewMeter but panics if the options are invalid.
+func MustNewMeter(opts ...Option) Meter {
+	m, err := NewMeter(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// The count and moving averages of a meter, which are swapped out together.
+type meterState struct {
+	count                atomic.Int64
+	rate1, rate5, rate15 *ewma
 }
 
-// Create a new meter.
-func NewMeter() Meter {
-	return &meter{
-		rate1:  NewEWMA1(),
-		rate5:  NewEWMA5(),
-		rate15: NewEWMA15(),
-		start:  time.Now(),
+func (m *meter) newState() *meterState {
+	return &meterState{
+		rate1:  newEWMA(ewmaAlpha(m.ticks, 1), m.ticks),
+		rate5:  newEWMA(ewmaAlpha(m.ticks, 5), m.ticks),
+		rate15: newEWMA(ewmaAlpha(m.ticks, 15), m.ticks),
+	}
+}
+
+func (s *meterState) mark(n int64) {
+	s.count.Add(n)
+	s.rate1.Update(n)
+	s.rate5.Update(n)
+	s.rate15.Update(n)
+}
+
+// Move the count and the events not yet ticked from old to s, returning the
+// count.
+func (s *meterState) drain(old *meterState) int64 {
+	s.rate1.Update(old.rate1.takeUncounted())
+	s.rate5.Update(old.rate5.takeUncounted())
+	s.rate15.Update(old.rate15.takeUncounted())
+	return old.count.Swap(0)
+}
+
+func (m *meter) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.state.Store(m.newState())
+	m.retired = nil
+	m.restart(m.now())
+	if m.gaps != nil {
+		m.marked = false
+		m.gaps.Clear()
 	}
 }
 
 func (m *meter) Count() int64 {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	return m.count
+	return m.state.Load().count.Load()
+}
+
+func (m *meter) InterArrival() Histogram {
+	return m.gaps
 }
 
 func (m *meter) Mark(n int64) {
+	if m.paused.Load() {
+		return
+	}
+	if m.gaps != nil {
+		m.arrive()
+	}
+	m.touch()
+	m.state.Load().mark(n)
+}
+
+func (m *meter) MarkBatch(ts []time.Time) {
+	if 0 == len(ts) || m.paused.Load() {
+		return
+	}
+	m.touch()
+	if m.gaps != nil {
+		gaps := make([]int64, 0, len(ts))
+		m.gapsMutex.Lock()
+		if m.marked {
+			gaps = append(gaps, int64(ts[0].Sub(m.last)))
+		}
+		m.last, m.marked = ts[len(ts)-1], true
+		m.gapsMutex.Unlock()
+		for i := 1; i < len(ts); i++ {
+			gaps = append(gaps, int64(ts[i].Sub(ts[i-1])))
+		}
+		m.gaps.UpdateBatch(gaps)
+	}
+	m.state.Load().mark(int64(len(ts)))
+}
+
+// Record the time since the previous mark.
+func (m *meter) arrive() {
+	m.gapsMutex.Lock()
+	defer m.gapsMutex.Unlock()
+	now := m.now()
+	if m.marked {
+		m.gaps.Update(int64(now.Sub(m.last)))
+	}
+	m.last, m.marked = now, true
+}
+
+func (m *meter) Pause() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	m.count += n
-	m.rate1.Update(n)
-	m.rate5.Update(n)
-	m.rate15.Update(n)
+	if !m.paused.Load() {
+		m.pausedAt = m.now()
+		m.paused.Store(true)
+	}
 }
 
-func (m *meter) Tick() {
+func (m *meter) Resume() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	m.rate1.Tick()
-	m.rate5.Tick()
-	m.rate15.Tick()
+	if m.paused.Load() {
+		m.pausedFor += m.now().Sub(m.pausedAt)
+		m.paused.Store(false)
+	}
+}
+
+func (m *meter) Tick() {
+	if m.paused.Load() {
+		return
+	}
+	s := m.state.Load()
+	s.rate1.Tick()
+	s.rate5.Tick()
+	s.rate15.Tick()
 }
 
 func (m *meter) Rate1() float64 {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	return m.rate1.Rate()
+	return m.state.Load().rate1.Rate() * m.scale
 }
 
 func (m *meter) Rate5() float64 {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	return m.rate5.Rate()
+	return m.state.Load().rate5.Rate() * m.scale
 }
 
 func (m *meter) Rate15() float64 {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	return m.rate15.Rate()
+	return m.state.Load().rate15.Rate() * m.scale
 }
 
 func (m *meter) RateMean() float64 {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-	return float64(m.count) / time.Since(m.start).Seconds()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return float64(m.Count()) / m.running(m.now()).Seconds() * m.scale
+}
+
+// Return how long the meter has not been paused between start and now; must
+// be called with the mutex held.
+func (m *meter) running(now time.Time) time.Duration {
+	d := now.Sub(m.start) - m.pausedFor
+	if m.paused.Load() {
+		d -= now.Sub(m.pausedAt)
+	}
+	return d
+}
+
+// Restart the mean rate calculation at now; must be called with the mutex
+// held.
+func (m *meter) restart(now time.Time) {
+	m.start = now
+	m.pausedFor = 0
+	if m.paused.Load() {
+		m.pausedAt = now
+	}
+}
+
+// Swapping in a fresh state reads and resets the count and rates together
+// without stopping marks.  Events marked since the last tick move to the
+// fresh state, so the next tick still counts them.
+func (m *meter) SnapshotAndReset() Meter {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := m.now()
+	fresh := m.newState()
+	old := m.state.Swap(fresh)
+	count := fresh.drain(old)
+	if m.retired != nil {
+		count += fresh.drain(m.retired)
+	}
+	m.retired = old
+	s := &meterSnapshot{
+		count:    count,
+		rate1:    old.rate1.Rate() * m.scale,
+		rate5:    old.rate5.Rate() * m.scale,
+		rate15:   old.rate15.Rate() * m.scale,
+		rateMean: float64(count) / m.running(now).Seconds() * m.scale,
+	}
+	s.frozenTimestamps = m.snapshot()
+	m.restart(now)
+	if m.gaps != nil {
+		s.gaps = m.gaps.SnapshotAndReset()
+	}
+	return s
+}
+
+// A read-only copy of a meter's count and rates.
+type meterSnapshot struct {
+	count                          int64
+	rate1, rate5, rate15, rateMean float64
+	gaps                           Histogram
+	frozenTimestamps
+}
+
+func (m *meterSnapshot) Clear() {
+	panic("metrics: Clear called on a meter snapshot")
+}
+
+func (m *meterSnapshot) Count() int64 { return m.count }
+
+func (m *meterSnapshot) InterArrival() Histogram { return m.gaps }
+
+func (m *meterSnapshot) Mark(int64) {
+	panic("metrics: Mark called on a meter snapshot")
+}
+
+func (m *meterSnapshot) MarkBatch([]time.Time) {
+	panic("metrics: MarkBatch called on a meter snapshot")
+}
+
+func (m *meterSnapshot) Pause() {
+	panic("metrics: Pause called on a meter snapshot")
+}
+
+func (m *meterSnapshot) Resume() {
+	panic("metrics: Resume called on a meter snapshot")
 }
+
+func (m *meterSnapshot) Tick() {
+	panic("metrics: Tick called on a meter snapshot")
+}
+
+func (m *meterSnapshot) Rate1() float64 { return m.rate1 }
+
+func (m *meterSnapshot) Rate5() float64 { return m.rate5 }
+
+func (m *meterSnapshot) Rate15() float64 { return m.rate15 }
+
+func (m *meterSnapshot) RateMean() float64 { return m.rateMean }
+
+func (m *meterSnapshot) SnapshotAndReset() Meter {
+	panic("metrics: SnapshotAndReset called on a meter snapshot")
+}
+
+func (m *meter) String() string { return formatMeter(m) }
+
+func (m *meterSnapshot) String() string { return formatMeter(m) }
+
+func (m *meter) MarshalJSON() ([]byte, error) { return marshalMeter(m) }
+
+func (m *meter) MarshalText() ([]byte, error) { return []byte(formatMeter(m)), nil }
+
+func (m *meterSnapshot) MarshalJSON() ([]byte, error) { return marshalMeter(m) }
+
+func (m *meterSnapshot) MarshalText() ([]byte, error) { return []byte(formatMeter(m)), nil }
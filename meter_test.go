@@ -1,16 +1,21 @@
 package metrics
 
-import "testing"
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
 
 func TestMeterZero(t *testing.T) {
-	m := NewMeter()
+	m := MustNewMeter()
 	if count := m.Count(); 0 != count {
 		t.Errorf("m.Count(): 0 != %v\n", count)
 	}
 }
 
 func TestMeterNonzero(t *testing.T) {
-	m := NewMeter()
+	m := MustNewMeter()
 	m.Mark(3)
 	if count := m.Count(); 3 != count {
 		t.Errorf("m.Count(): 3 != %v\n", count)
@@ -18,7 +23,7 @@ func TestMeterNonzero(t *testing.T) {
 }
 
 func TestMeterRate1(t *testing.T) {
-	m := NewMeter()
+	m := MustNewMeter()
 	m.Mark(3)
 	m.Tick()
 	const expected = 0.6
@@ -26,3 +31,260 @@ func TestMeterRate1(t *testing.T) {
 		t.Errorf("m.Rate1(): %v != %v\n", expected, r1)
 	}
 }
+
+func TestMeterTickInterval(t *testing.T) {
+	m := MustNewMeter(WithTickInterval(time.Second))
+	m.Mark(3)
+	m.Tick()
+	const expected = 3.0
+	if r1 := m.Rate1(); r1 != expected {
+		t.Errorf("m.Rate1(): %v != %v\n", expected, r1)
+	}
+}
+
+func TestMeterRateMeanClock(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := MustNewMeter(WithClock(func() time.Time { return now }))
+	m.Mark(10)
+	now = now.Add(5 * time.Second)
+	const expected = 2.0
+	if r := m.RateMean(); r != expected {
+		t.Errorf("m.RateMean(): %v != %v\n", expected, r)
+	}
+}
+
+func TestMeterRateUnit(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := MustNewMeter(WithClock(func() time.Time { return now }), WithRateUnit(time.Minute))
+	m.Mark(10)
+	now = now.Add(5 * time.Second)
+	const expected = 120.0
+	if r := m.RateMean(); r != expected {
+		t.Errorf("m.RateMean(): %v != %v\n", expected, r)
+	}
+	m.Tick()
+	if r1, r := m.Rate1(), 60*m.(*meter).state.Load().rate1.Rate(); r1 != r {
+		t.Errorf("m.Rate1(): %v != %v\n", r, r1)
+	}
+	if r := m.SnapshotAndReset().RateMean(); r != expected {
+		t.Errorf("m.SnapshotAndReset().RateMean(): %v != %v\n", expected, r)
+	}
+	if _, err := NewMeter(WithRateUnit(0)); err == nil {
+		t.Error("NewMeter(WithRateUnit(0)): expected error")
+	}
+}
+
+func TestMeterPause(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := MustNewMeter(WithClock(func() time.Time { return now }))
+	m.Mark(10)
+	now = now.Add(5 * time.Second)
+	m.Pause()
+	m.Mark(100)
+	m.Tick()
+	now = now.Add(time.Hour)
+	if r1 := m.Rate1(); 0 != r1 {
+		t.Errorf("m.Rate1(): 0 != %v\n", r1)
+	}
+	if r := m.RateMean(); 2 != r {
+		t.Errorf("m.RateMean(): 2 != %v\n", r)
+	}
+	m.Resume()
+	m.Mark(10)
+	now = now.Add(5 * time.Second)
+	if count := m.Count(); 20 != count {
+		t.Errorf("m.Count(): 20 != %v\n", count)
+	}
+	if r := m.SnapshotAndReset().RateMean(); 2 != r {
+		t.Errorf("m.SnapshotAndReset().RateMean(): 2 != %v\n", r)
+	}
+}
+
+func TestMeterInvalidTickInterval(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second, time.Minute} {
+		if _, err := NewMeter(WithTickInterval(d)); err == nil {
+			t.Errorf("NewMeter(WithTickInterval(%v)): expected error", d)
+		}
+	}
+}
+
+func TestMeterMarkAllocs(t *testing.T) {
+	m := MustNewMeter()
+	if n := testing.AllocsPerRun(100, func() { m.Mark(1) }); 0 != n {
+		t.Errorf("allocations per m.Mark(): 0 != %v\n", n)
+	}
+}
+
+func BenchmarkMeterMark(b *testing.B) {
+	m := MustNewMeter()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Mark(1)
+		}
+	})
+}
+
+func TestMeterClear(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := MustNewMeter(WithClock(func() time.Time { return now }))
+	m.Mark(3)
+	m.Tick()
+	now = now.Add(time.Minute)
+	m.Clear()
+	if count := m.Count(); 0 != count {
+		t.Errorf("m.Count(): 0 != %v\n", count)
+	}
+	if r1 := m.Rate1(); 0 != r1 {
+		t.Errorf("m.Rate1(): 0 != %v\n", r1)
+	}
+	m.Mark(4)
+	now = now.Add(2 * time.Second)
+	if r := m.RateMean(); 2 != r {
+		t.Errorf("m.RateMean(): 2 != %v\n", r)
+	}
+}
+
+func TestMeterSnapshotAndReset(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := MustNewMeter(WithClock(func() time.Time { return now }))
+	m.Mark(3)
+	m.Tick()
+	now = now.Add(time.Second)
+	s := m.SnapshotAndReset()
+	if count := s.Count(); 3 != count {
+		t.Errorf("s.Count(): 3 != %v\n", count)
+	}
+	if r1 := s.Rate1(); 0.6 != r1 {
+		t.Errorf("s.Rate1(): 0.6 != %v\n", r1)
+	}
+	if r := s.RateMean(); 3 != r {
+		t.Errorf("s.RateMean(): 3 != %v\n", r)
+	}
+	if count := m.Count(); 0 != count {
+		t.Errorf("m.Count(): 0 != %v\n", count)
+	}
+	if r1 := m.Rate1(); 0 != r1 {
+		t.Errorf("m.Rate1(): 0 != %v\n", r1)
+	}
+}
+
+func TestMeterSnapshotAndResetPending(t *testing.T) {
+	m := MustNewMeter()
+	m.Mark(3)
+	m.SnapshotAndReset()
+	m.Tick()
+	if r1 := m.Rate1(); 0.6 != r1 {
+		t.Errorf("m.Rate1(): 0.6 != %v\n", r1)
+	}
+}
+
+func TestMeterSnapshotAndResetConcurrent(t *testing.T) {
+	m := MustNewMeter()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Mark(1)
+			}
+		}()
+	}
+	var count int64
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			count += m.SnapshotAndReset().Count()
+			if 4000 != count {
+				t.Errorf("sum of snapshot counts: 4000 != %v\n", count)
+			}
+			return
+		default:
+			count += m.SnapshotAndReset().Count()
+		}
+	}
+}
+
+func TestMeterInterArrival(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := MustNewMeter(
+		WithClock(func() time.Time { return now }),
+		WithInterArrival(func() Sample { return MustNewUniformSample(100) }),
+	)
+	for _, d := range []time.Duration{0, time.Second, 3 * time.Second} {
+		now = now.Add(d)
+		m.Mark(1)
+	}
+	h := m.InterArrival()
+	if count := h.Count(); 2 != count {
+		t.Errorf("h.Count(): 2 != %v\n", count)
+	}
+	if min := h.Min(); int64(time.Second) != min {
+		t.Errorf("h.Min(): %v != %v\n", int64(time.Second), min)
+	}
+	if max := h.Max(); int64(3*time.Second) != max {
+		t.Errorf("h.Max(): %v != %v\n", int64(3*time.Second), max)
+	}
+	if h := MustNewMeter().InterArrival(); nil != h {
+		t.Errorf("InterArrival() without option: nil != %v\n", h)
+	}
+}
+
+func TestMeterInterArrivalShared(t *testing.T) {
+	opt := WithInterArrival(func() Sample { return MustNewUniformSample(100) })
+	m1, m2 := MustNewMeter(opt), MustNewMeter(opt)
+	m1.Mark(1)
+	m1.Mark(1)
+	if count := m2.InterArrival().Count(); 0 != count {
+		t.Errorf("m2.InterArrival().Count(): 0 != %v\n", count)
+	}
+	if _, err := NewMeter(WithInterArrival(func() Sample { return nil })); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewMeter with a nil inter-arrival sample: %v\n", err)
+	}
+}
+
+func TestMeterInterArrivalConcurrent(t *testing.T) {
+	m := MustNewMeter(WithInterArrival(func() Sample { return MustNewUniformSample(1028) }))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Mark(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if min := m.InterArrival().Min(); min < 0 {
+		t.Errorf("m.InterArrival().Min(): %v < 0\n", min)
+	}
+}
+
+func TestMeterMarkBatch(t *testing.T) {
+	start := time.Unix(0, 0)
+	m := MustNewMeter(
+		WithClock(func() time.Time { return start }),
+		WithInterArrival(func() Sample { return MustNewUniformSample(100) }),
+	)
+	m.Mark(1)
+	m.MarkBatch([]time.Time{
+		start.Add(time.Second),
+		start.Add(3 * time.Second),
+	})
+	if count := m.Count(); 3 != count {
+		t.Errorf("m.Count(): 3 != %v\n", count)
+	}
+	h := m.InterArrival()
+	if count := h.Count(); 2 != count {
+		t.Errorf("h.Count(): 2 != %v\n", count)
+	}
+	if max := h.Max(); int64(2*time.Second) != max {
+		t.Errorf("h.Max(): %v != %v\n", int64(2*time.Second), max)
+	}
+}
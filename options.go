@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Option configures a metric at construction time.  Constructors silently
+// ignore options which do not apply to the metric being created.
+type Option func(*options)
+
+type options struct {
+	reservoirSize int
+	alpha         float64
+	now           func() time.Time
+	tickInterval  time.Duration
+	unit          time.Duration
+	rateUnit      time.Duration
+	interArrival  func() Sample
+	noReservoir   bool
+	recent        int
+	landmark      time.Duration
+	invalid       InvalidPolicy
+	sampleRate    float64
+	sampleN       int
+	lastUpdate    bool
+	maxDuration   time.Duration
+	tapN          int
+}
+
+// Create options with the package defaults and apply opts on top of them.
+func newOptions(opts []Option) options {
+	o := options{
+		reservoirSize: 1028,
+		alpha:         0.015,
+		now:           time.Now,
+		tickInterval:  TickDuration,
+		unit:          time.Nanosecond,
+		rateUnit:      time.Second,
+		landmark:      rescaleThreshold,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithReservoirSize sets the reservoir size of the sample a Timer creates for
+// its histogram.  The default is 1028.
+func WithReservoirSize(n int) Option {
+	return func(o *options) { o.reservoirSize = n }
+}
+
+// WithAlpha sets the alpha of the exponentially-decaying sample a Timer
+// creates for its histogram.  The default is 0.015.
+func WithAlpha(alpha float64) Option {
+	return func(o *options) { o.alpha = alpha }
+}
+
+// WithClock sets the function used to read the current time.  It is honored
+// by exponentially-decaying samples and by all the standard metrics for their
+// creation and last update times.  The default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) { o.now = now }
+}
+
+// WithLastUpdate makes counters, gauges, histograms, meters and timers record
+// the time of their last update, which LastUpdate returns.  It costs a clock
+// reading on every update, so it is off by default.
+func WithLastUpdate() Option {
+	return func(o *options) { o.lastUpdate = true }
+}
+
+// WithTickInterval sets the interval at which the caller is going to call
+// Tick() on meters and timers.  The default is TickDuration.
+func WithTickInterval(d time.Duration) Option {
+	return func(o *options) { o.tickInterval = d }
+}
+
+// WithUnit sets the unit in which a Timer records durations, so that its
+// Min, Max, Mean, Percentile and StdDev are expressed in that unit.  The
+// default is time.Nanosecond.
+func WithUnit(d time.Duration) Option {
+	return func(o *options) { o.unit = d }
+}
+
+// WithRateUnit sets the unit of time meters and timers report rates per,
+// for example time.Hour for events per hour.  The default is time.Second.
+func WithRateUnit(d time.Duration) Option {
+	return func(o *options) { o.rateUnit = d }
+}
+
+// WithRecent makes samples also keep the last n values in arrival order,
+// returned by Recent.  The default is to keep none.
+func WithRecent(n int) Option {
+	return func(o *options) { o.recent = n }
+}
+
+// WithLandmarkRescale sets how often a weighted exponentially-decaying
+// sample moves its landmark, the time weights are measured from, to the
+// current time.  Zero keeps the landmark where the sample was created or
+// last cleared.  The default is one hour.
+func WithLandmarkRescale(d time.Duration) Option {
+	return func(o *options) { o.landmark = d }
+}
+
+// WithoutReservoir makes a Timer keep only the count, min, max, mean and
+// standard deviation of durations, without a sample, so that updates are as
+// cheap as possible.  Its percentiles are then always zero.
+func WithoutReservoir() Option {
+	return func(o *options) { o.noReservoir = true }
+}
+
+// WithInvalidPolicy sets what a Timer does with durations which are zero or
+// negative.  The default is RecordInvalid.
+func WithInvalidPolicy(p InvalidPolicy) Option {
+	return func(o *options) { o.invalid = p }
+}
+
+// WithMaxDuration sets the longest duration a Timer's UpdateSince accepts
+// when either time lacks a monotonic clock reading.  Longer durations are
+// taken for a step of the wall clock, counted as skewed and recorded as d.
+// The default, zero, sets no limit.
+func WithMaxDuration(d time.Duration) Option {
+	return func(o *options) { o.maxDuration = d }
+}
+
+// WithInterArrival makes meters and timers also track the distribution of
+// times between consecutive events, in nanoseconds, in a histogram.  Each
+// meter calls newSample for a sample of its own, so the option can be shared
+// between constructors.
+func WithInterArrival(newSample func() Sample) Option {
+	return func(o *options) { o.interArrival = newSample }
+}
+
+// WithAdaptiveSampling makes histograms and timers record only one in n
+// updates, chosen at random, while updates arrive faster than threshold per
+// second.  Recorded updates are weighted by n in the count, mean and
+// variance.  The rate is measured over one-second windows.  An n of 1 or
+// less disables sampling.
+func WithAdaptiveSampling(threshold float64, n int) Option {
+	return func(o *options) {
+		o.sampleRate = threshold
+		o.sampleN = n
+	}
+}
+
+// WithTapSampling makes a tap histogram write only one in n updates, chosen
+// at random.  All updates are still passed on.  An n of 1 or less writes
+// every update.
+func WithTapSampling(n int) Option {
+	return func(o *options) { o.tapN = n }
+}
+
+// ErrInvalidArgument is matched by errors.Is for the errors constructors
+// return when given an invalid argument or option.
+var ErrInvalidArgument = errors.New("metrics: invalid argument")
+
+// An error describing an invalid argument, which matches ErrInvalidArgument.
+type argumentError string
+
+func (e argumentError) Error() string { return "metrics: " + string(e) }
+
+func (e argumentError) Is(target error) bool { return target == ErrInvalidArgument }
+
+func invalidf(format string, args ...interface{}) error {
+	return argumentError(fmt.Sprintf(format, args...))
+}
+
+func checkReservoirSize(n int) error {
+	if n <= 0 {
+		return invalidf("reservoir size must be positive, got %d", n)
+	}
+	return nil
+}
+
+func checkAlpha(alpha float64) error {
+	if !(alpha > 0 && alpha < 1) {
+		return invalidf("alpha must be in (0, 1), got %v", alpha)
+	}
+	return nil
+}
+
+func checkLandmarkRescale(d time.Duration) error {
+	if d < 0 {
+		return invalidf("landmark rescale interval must not be negative, got %v", d)
+	}
+	return nil
+}
+
+func checkInvalidPolicy(p InvalidPolicy) error {
+	if p < RecordInvalid || p > ClampInvalid {
+		return invalidf("unknown invalid duration policy %d", p)
+	}
+	return nil
+}
+
+func checkMaxDuration(d time.Duration) error {
+	if d < 0 {
+		return invalidf("max duration must not be negative, got %v", d)
+	}
+	return nil
+}
+
+func checkRecent(n int) error {
+	if n < 0 {
+		return invalidf("recent value count must not be negative, got %d", n)
+	}
+	return nil
+}
+
+// Tick interval has to be shorter than the shortest moving average window,
+// which is one minute.
+func checkTickInterval(d time.Duration) error {
+	if d <= 0 || d >= time.Minute {
+		return invalidf("tick interval must be in (0, 1m), got %v", d)
+	}
+	return nil
+}
+
+func checkClock(now func() time.Time) error {
+	if now == nil {
+		return invalidf("clock must not be nil")
+	}
+	return nil
+}
+
+func checkUnit(d time.Duration) error {
+	if d <= 0 {
+		return invalidf("unit must be positive, got %v", d)
+	}
+	return nil
+}
+
+func checkRateUnit(d time.Duration) error {
+	if d <= 0 {
+		return invalidf("rate unit must be positive, got %v", d)
+	}
+	return nil
+}
+
+// Return the first non-nil error.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+)
+
+// PressureSignals are the inputs of a Pressure: a value to read, such as
+// the value of an in-flight requests gauge, a timer's percentile or a
+// scheduler collector's saturation, and the limit at which that value alone
+// means full pressure.
+type PressureSignal struct {
+	Read  func() float64
+	Limit float64
+}
+
+// Pressures combine several signals into a single score meant to drive
+// admission control, and decide with hysteresis whether to shed load.  Tick
+// reads the signals and updates the score.
+type Pressure interface {
+	Tickable
+
+	// Return the gauge of the score: the highest of the signals' values, in
+	// percent of their limits.
+	Score() Gauge
+
+	// Return whether load should be shed.  It becomes true when the score
+	// reaches the high watermark and false again only when it falls to the
+	// low one, so that admission does not flap around a single threshold.
+	Shedding() bool
+}
+
+// The standard implementation of a Pressure takes the maximum of the
+// signals, since a single exhausted resource is enough to warrant shedding.
+type pressure struct {
+	signals   []PressureSignal
+	high, low float64
+	score     Gauge
+	mutex     sync.Mutex
+	shedding  bool
+}
+
+// Create a new pressure over the given signals, whose Read must not be nil
+// and whose limits must be positive.  Watermarks are in percent and low must
+// be less than high.  Readings which are NaN or infinite are skipped, and a
+// Tick with no other readings leaves the score and shedding as they were.
+func NewPressure(signals []PressureSignal, low, high float64) (Pressure, error) {
+	if 0 == len(signals) {
+		return nil, invalidf("pressure needs at least one signal")
+	}
+	for _, s := range signals {
+		if nil == s.Read {
+			return nil, invalidf("signal read must not be nil")
+		}
+		if !(s.Limit > 0) {
+			return nil, invalidf("signal limit must be positive, got %v", s.Limit)
+		}
+	}
+	if !(low < high) {
+		return nil, invalidf("low watermark must be less than high, got %v and %v", low, high)
+	}
+	return &pressure{
+		signals: append([]PressureSignal(nil), signals...),
+		high:    high,
+		low:     low,
+		score:   NewGauge(),
+	}, nil
+}
+
+// Like NewPressure but panics if the arguments are invalid.
+func MustNewPressure(signals []PressureSignal, low, high float64) Pressure {
+	p, err := NewPressure(signals, low, high)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *pressure) Score() Gauge {
+	return p.score
+}
+
+func (p *pressure) Shedding() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.shedding
+}
+
+func (p *pressure) Tick() {
+	score, read := 0.0, false
+	for _, s := range p.signals {
+		x := s.Read()
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			continue
+		}
+		score, read = math.Max(score, 100*x/s.Limit), true
+	}
+	if !read {
+		return
+	}
+	p.score.Update(saturate(score))
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if score >= p.high {
+		p.shedding = true
+	} else if score <= p.low {
+		p.shedding = false
+	}
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPressure(t *testing.T) {
+	inFlight := NewGauge()
+	tm := MustNewTimer()
+	p := MustNewPressure([]PressureSignal{
+		{func() float64 { return float64(inFlight.Value()) }, 100},
+		{func() float64 { return tm.Percentile(0.99) }, float64(time.Second)},
+	}, 70, 90)
+	for _, tc := range []struct {
+		inFlight int64
+		latency  time.Duration
+		score    int64
+		shedding bool
+	}{
+		{50, 0, 50, false},
+		{50, 2 * time.Second, 200, true},
+		{80, 0, 80, true},
+		{70, 0, 70, false},
+		{80, 0, 80, false},
+	} {
+		inFlight.Update(tc.inFlight)
+		tm.SnapshotAndReset()
+		if 0 != tc.latency {
+			tm.Update(tc.latency)
+		}
+		p.Tick()
+		if score := p.Score().Value(); tc.score != score {
+			t.Errorf("p.Score().Value(): %v != %v\n", tc.score, score)
+		}
+		if shedding := p.Shedding(); tc.shedding != shedding {
+			t.Errorf("score %v: p.Shedding(): %v != %v\n", tc.score, tc.shedding, shedding)
+		}
+	}
+}
+
+func TestPressureNonFiniteSignals(t *testing.T) {
+	x := math.NaN()
+	p := MustNewPressure([]PressureSignal{
+		{func() float64 { return x }, 100},
+		{func() float64 { return 95 }, 100},
+	}, 70, 90)
+	p.Tick()
+	if score := p.Score().Value(); 95 != score {
+		t.Errorf("p.Score().Value(): 95 != %v\n", score)
+	}
+	if !p.Shedding() {
+		t.Error("p.Shedding(): false with a NaN signal and one at 95%")
+	}
+	x = math.Inf(1)
+	p.Tick()
+	if score := p.Score().Value(); 95 != score {
+		t.Errorf("p.Score().Value() with an infinite signal: 95 != %v\n", score)
+	}
+	p = MustNewPressure([]PressureSignal{{func() float64 { return x }, 100}}, 70, 90)
+	p.Tick()
+	if score := p.Score().Value(); 0 != score {
+		t.Errorf("p.Score().Value() without finite signals: 0 != %v\n", score)
+	}
+	x = math.MaxFloat64
+	p.Tick()
+	if score := p.Score().Value(); math.MaxInt64 != score {
+		t.Errorf("p.Score().Value(): %v != %v\n", int64(math.MaxInt64), score)
+	}
+}
+
+func TestPressureInvalidArguments(t *testing.T) {
+	read := func() float64 { return 0 }
+	for _, tc := range []struct {
+		signals   []PressureSignal
+		low, high float64
+	}{
+		{nil, 70, 90},
+		{[]PressureSignal{{read, 0}}, 70, 90},
+		{[]PressureSignal{{read, 1}}, 90, 70},
+		{[]PressureSignal{{nil, 1}}, 70, 90},
+	} {
+		if _, err := NewPressure(tc.signals, tc.low, tc.high); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("NewPressure(%v, %v, %v): %v is not ErrInvalidArgument", tc.signals, tc.low, tc.high, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package metrics
+
+// ProcessCollectors expose the number of open file descriptors of the
+// current process and the number of TCP sockets in each state as gauges.
+// Tick reads current values into the gauges; values which cannot be read
+// are left unchanged.
+type ProcessCollector interface {
+	Tickable
+
+	// Return the gauge of file descriptors open by the process.
+	OpenFDs() Gauge
+
+	// Return the gauge of TCP sockets in the given state, named like
+	// "ESTABLISHED", "TIME_WAIT" or "CLOSE_WAIT", or nil for an unknown
+	// state.
+	TCPSockets(state string) Gauge
+}
+
+// TCP socket states in the order of their numbering in the kernel, starting
+// from 1.
+var tcpStates = []string{
+	"ESTABLISHED",
+	"SYN_SENT",
+	"SYN_RECV",
+	"FIN_WAIT1",
+	"FIN_WAIT2",
+	"TIME_WAIT",
+	"CLOSE",
+	"CLOSE_WAIT",
+	"LAST_ACK",
+	"LISTEN",
+	"CLOSING",
+}
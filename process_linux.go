@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The Linux implementation of a ProcessCollector reads /proc.  Socket counts
+// cover the whole network namespace, since sockets in states such as
+// TIME_WAIT no longer belong to any file descriptor.
+type processCollector struct {
+	root    string
+	fds     Gauge
+	sockets map[string]Gauge
+}
+
+// Create a new process collector.
+func NewProcessCollector() (ProcessCollector, error) {
+	return newProcessCollector("/proc"), nil
+}
+
+func newProcessCollector(root string) *processCollector {
+	c := &processCollector{
+		root:    root,
+		fds:     NewGauge(),
+		sockets: make(map[string]Gauge, len(tcpStates)),
+	}
+	for _, state := range tcpStates {
+		c.sockets[state] = NewGauge()
+	}
+	return c
+}
+
+func (c *processCollector) OpenFDs() Gauge {
+	return c.fds
+}
+
+func (c *processCollector) TCPSockets(state string) Gauge {
+	return c.sockets[state]
+}
+
+func (c *processCollector) Tick() {
+	if n, err := countFDs(filepath.Join(c.root, "self", "fd")); err == nil {
+		c.fds.Update(n)
+	}
+	counts := make([]int64, len(tcpStates))
+	for _, name := range []string{"tcp", "tcp6"} {
+		// Without IPv6 there is no tcp6 file, and so no IPv6 sockets.
+		err := countTCPStates(filepath.Join(c.root, "net", name), counts)
+		if err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+	for i, state := range tcpStates {
+		c.sockets[state].Update(counts[i])
+	}
+}
+
+// Return the number of file descriptors listed in a /proc/self/fd style
+// directory, leaving out the one opened to read it.
+func countFDs(name string) (int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fds, err := f.ReadDir(-1)
+	if err != nil {
+		return 0, err
+	}
+	self := strconv.FormatUint(uint64(f.Fd()), 10)
+	n := int64(0)
+	for _, fd := range fds {
+		if fd.Name() != self {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Add the number of sockets in each state listed in a /proc/net/tcp style
+// file to counts.
+func countTCPStates(name string, counts []int64) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		st, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil || st < 1 || int(st) > len(counts) {
+			continue
+		}
+		counts[st-1]++
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcessCollector(t *testing.T) {
+	root := t.TempDir()
+	fd := filepath.Join(root, "self", "fd")
+	if err := os.MkdirAll(fd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0", "1", "2"} {
+		if err := os.WriteFile(filepath.Join(fd, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "net"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	const header = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	tcp := header +
+		"   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1 1 0 100 0 0 10 0\n" +
+		"   1: 0100007F:1F90 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 2 1 0 20 4 30 10 -1\n" +
+		"   2: 0100007F:1F90 0100007F:D432 06 00000000:00000000 03:00000F9C 00000000     0        0 0 3 0\n"
+	tcp6 := header +
+		"   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 01 00000000:00000000 00:00000000 00000000  1000        0 3 1 0 100 0 0 10 0\n"
+	if err := os.WriteFile(filepath.Join(root, "net", "tcp"), []byte(tcp), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "net", "tcp6"), []byte(tcp6), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newProcessCollector(root)
+	c.Tick()
+	if v := c.OpenFDs().Value(); 3 != v {
+		t.Errorf("c.OpenFDs().Value(): 3 != %v\n", v)
+	}
+	for state, expected := range map[string]int64{
+		"ESTABLISHED": 2,
+		"TIME_WAIT":   1,
+		"LISTEN":      1,
+		"CLOSE_WAIT":  0,
+	} {
+		if v := c.TCPSockets(state).Value(); expected != v {
+			t.Errorf("c.TCPSockets(%q).Value(): %v != %v\n", state, expected, v)
+		}
+	}
+	if g := c.TCPSockets("BOGUS"); nil != g {
+		t.Errorf("c.TCPSockets(\"BOGUS\"): nil != %v\n", g)
+	}
+	if err := os.Remove(filepath.Join(root, "net", "tcp6")); err != nil {
+		t.Fatal(err)
+	}
+	c.Tick()
+	if v := c.TCPSockets("ESTABLISHED").Value(); 1 != v {
+		t.Errorf("c.TCPSockets(\"ESTABLISHED\").Value() without tcp6: 1 != %v\n", v)
+	}
+}
+
+func TestProcessCollectorSelf(t *testing.T) {
+	c, err := NewProcessCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Tick()
+	if v := c.OpenFDs().Value(); v < 3 {
+		t.Errorf("c.OpenFDs().Value(): %v < 3\n", v)
+	}
+}
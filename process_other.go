@@ -0,0 +1,15 @@
+//go:build !linux
+
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// Create a new process collector.  It is only supported on Linux, elsewhere
+// the error matches errors.ErrUnsupported.
+func NewProcessCollector() (ProcessCollector, error) {
+	return nil, fmt.Errorf("metrics: process collector: %w on %s", errors.ErrUnsupported, runtime.GOOS)
+}
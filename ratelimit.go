@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// A histogram which passes at most a fixed number of updates per second on to
+// another histogram, so that a hot loop can not spend its time in an
+// expensive sample.  Updates are admitted by a token bucket which holds up to
+// one second's worth of updates, or one update for limits below one per
+// second.  Excess updates are coalesced: the smallest and largest of them are
+// held back and passed on ahead of new updates once there are tokens again,
+// or on SnapshotAndReset, and the others are counted.  The histogram's min
+// and max are exact only once held back updates have been passed on; if
+// updates stop, they wait for the next SnapshotAndReset.
+type rateLimitedHistogram struct {
+	Histogram
+	coalesced Counter
+	limit     float64
+	burst     float64
+	mutex     sync.Mutex
+	now       func() time.Time
+	tokens    float64
+	last      time.Time
+	pending   []int64 // sorted, at most two values
+	side      int     // which of two held back values to release first
+}
+
+// Create a new histogram which updates h at most limit times per second.
+// Updates over the limit are coalesced: the returned counter counts those
+// which never reach h.  Limit must be positive.  It honors the WithClock
+// option.
+func NewRateLimitedHistogram(h Histogram, limit float64, opts ...Option) (Histogram, Counter, error) {
+	o := newOptions(opts)
+	if err := firstError(checkLimit(limit), checkClock(o.now)); err != nil {
+		return nil, nil, err
+	}
+	r := &rateLimitedHistogram{
+		Histogram: h,
+		coalesced: NewCounter(),
+		limit:     limit,
+		burst:     math.Max(limit, 1),
+		now:       o.now,
+		pending:   make([]int64, 0, 2),
+	}
+	r.tokens = r.burst
+	r.last = r.now()
+	return r, r.coalesced, nil
+}
+
+// Like NewRateLimitedHistogram but panics if the arguments are invalid.
+func MustNewRateLimitedHistogram(h Histogram, limit float64, opts ...Option) (Histogram, Counter) {
+	r, c, err := NewRateLimitedHistogram(h, limit, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return r, c
+}
+
+func (r *rateLimitedHistogram) Update(v int64) {
+	r.mutex.Lock()
+	held := r.release()
+	n := r.take(1)
+	if 0 == n {
+		r.coalesce(v)
+	}
+	r.mutex.Unlock()
+	if len(held) > 0 {
+		r.Histogram.UpdateBatch(held)
+	}
+	if n > 0 {
+		r.Histogram.Update(v)
+	}
+}
+
+func (r *rateLimitedHistogram) UpdateBatch(vs []int64) {
+	r.mutex.Lock()
+	held := r.release()
+	n := r.take(len(vs))
+	for _, v := range vs[n:] {
+		r.coalesce(v)
+	}
+	r.mutex.Unlock()
+	if len(held) > 0 {
+		r.Histogram.UpdateBatch(held)
+	}
+	if n > 0 {
+		r.Histogram.UpdateBatch(vs[:n])
+	}
+}
+
+// Clear the histogram and drop the updates held back for it.
+func (r *rateLimitedHistogram) Clear() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pending = r.pending[:0]
+	r.Histogram.Clear()
+}
+
+// Pass held back updates on regardless of the limit, so that they are part
+// of the snapshot, and return it.
+func (r *rateLimitedHistogram) SnapshotAndReset() Histogram {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.pending) > 0 {
+		r.Histogram.UpdateBatch(r.pending)
+		r.pending = r.pending[:0]
+	}
+	return r.Histogram.SnapshotAndReset()
+}
+
+// Refill the bucket and take up to n tokens from it, returning how many were
+// taken; must be called with the mutex held.
+func (r *rateLimitedHistogram) take(n int) int {
+	now := r.now()
+	if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens = math.Min(r.tokens+elapsed.Seconds()*r.limit, r.burst)
+	}
+	r.last = now
+	if available := int(r.tokens); available < n {
+		n = available
+	}
+	r.tokens -= float64(n)
+	return n
+}
+
+// Hold back an excess update if it is a new extreme and count the update
+// which it displaces or is, otherwise; must be called with the mutex held.
+func (r *rateLimitedHistogram) coalesce(v int64) {
+	p := r.pending
+	switch {
+	case 0 == len(p):
+		r.pending = append(p, v)
+		return
+	case 1 == len(p) && v != p[0]:
+		p = append(p, v)
+		if v < p[0] {
+			p[0], p[1] = p[1], p[0]
+		}
+		r.pending = p
+		return
+	case 2 == len(p) && v < p[0]:
+		p[0] = v
+	case 2 == len(p) && v > p[1]:
+		p[1] = v
+	}
+	r.coalesced.Inc(1)
+}
+
+// Take tokens for as many held back updates as possible and return those
+// updates; must be called with the mutex held.  When only one of two can be
+// released, the smallest and the largest take turns, so that neither waits
+// forever while the histogram stays over its limit.
+func (r *rateLimitedHistogram) release() []int64 {
+	p := r.pending
+	switch r.take(len(p)) {
+	case 0:
+		return nil
+	case len(p):
+		r.pending = p[:0]
+		return append([]int64(nil), p...)
+	}
+	held := []int64{p[r.side]}
+	p[0] = p[1-r.side]
+	r.pending = p[:1]
+	r.side = 1 - r.side
+	return held
+}
+
+func checkLimit(limit float64) error {
+	if !(limit > 0) {
+		return invalidf("limit must be positive, got %v", limit)
+	}
+	return nil
+}
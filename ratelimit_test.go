@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitedHistogram(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := MustNewHistogram(MustNewUniformSample(100))
+	r, coalesced := MustNewRateLimitedHistogram(h, 10, WithClock(func() time.Time { return now }))
+	for i := 0; i < 15; i++ {
+		r.Update(int64(i))
+	}
+	if count := h.Count(); 10 != count {
+		t.Errorf("h.Count(): 10 != %v\n", count)
+	}
+	if count := coalesced.Count(); 3 != count {
+		t.Errorf("coalesced.Count(): 3 != %v\n", count)
+	}
+	now = now.Add(500 * time.Millisecond)
+	r.UpdateBatch([]int64{1, 2, 3, 4, 5, 6, 7, 8})
+	if count := h.Count(); 15 != count {
+		t.Errorf("h.Count(): 15 != %v\n", count)
+	}
+	if count := coalesced.Count(); 6 != count {
+		t.Errorf("coalesced.Count(): 6 != %v\n", count)
+	}
+	now = now.Add(time.Second)
+	r.Update(100)
+	if count := h.Count(); 18 != count {
+		t.Errorf("h.Count(): 18 != %v\n", count)
+	}
+	if min := h.Min(); 0 != min {
+		t.Errorf("h.Min(): 0 != %v\n", min)
+	}
+	if max := h.Max(); 100 != max {
+		t.Errorf("h.Max(): 100 != %v\n", max)
+	}
+	r.Update(200)
+	if count := h.Count() + coalesced.Count(); 25 != count {
+		t.Errorf("h.Count() + coalesced.Count(): 25 != %v\n", count)
+	}
+}
+
+func TestRateLimitedHistogramCoalesceExtremes(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := MustNewHistogram(MustNewUniformSample(100))
+	r, _ := MustNewRateLimitedHistogram(h, 1, WithClock(func() time.Time { return now }))
+	r.Update(50)
+	r.UpdateBatch([]int64{40, 60, 10, 90, 30})
+	now = now.Add(2 * time.Second)
+	r.Update(50)
+	now = now.Add(time.Second)
+	r.Update(50)
+	if min := h.Min(); 10 != min {
+		t.Errorf("h.Min(): 10 != %v\n", min)
+	}
+	if max := h.Max(); 90 != max {
+		t.Errorf("h.Max(): 90 != %v\n", max)
+	}
+}
+
+func TestRateLimitedHistogramBelowOne(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := MustNewHistogram(MustNewUniformSample(100))
+	r, _ := MustNewRateLimitedHistogram(h, 0.5, WithClock(func() time.Time { return now }))
+	r.Update(1)
+	r.Update(1)
+	if count := h.Count(); 1 != count {
+		t.Errorf("h.Count(): 1 != %v\n", count)
+	}
+	now = now.Add(2 * time.Second)
+	r.Update(1)
+	if count := h.Count(); 2 != count {
+		t.Errorf("h.Count(): 2 != %v\n", count)
+	}
+}
+
+func TestRateLimitedHistogramClear(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := MustNewHistogram(MustNewUniformSample(100))
+	r, _ := MustNewRateLimitedHistogram(h, 1, WithClock(func() time.Time { return now }))
+	r.UpdateBatch([]int64{50, 10, 90})
+	r.Clear()
+	now = now.Add(2 * time.Second)
+	r.Update(50)
+	if count := h.Count(); 1 != count {
+		t.Errorf("h.Count(): 1 != %v\n", count)
+	}
+	if min, max := h.Min(), h.Max(); 50 != min || 50 != max {
+		t.Errorf("h.Min(), h.Max(): 50, 50 != %v, %v\n", min, max)
+	}
+}
+
+func TestRateLimitedHistogramSnapshotAndReset(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := MustNewHistogram(MustNewUniformSample(100))
+	r, coalesced := MustNewRateLimitedHistogram(h, 1, WithClock(func() time.Time { return now }))
+	r.UpdateBatch([]int64{50, 40, 60, 10, 90, 30})
+	s := r.SnapshotAndReset()
+	if count := s.Count(); 3 != count {
+		t.Errorf("s.Count(): 3 != %v\n", count)
+	}
+	if min, max := s.Min(), s.Max(); 10 != min || 90 != max {
+		t.Errorf("s.Min(), s.Max(): 10, 90 != %v, %v\n", min, max)
+	}
+	if count := coalesced.Count(); 3 != count {
+		t.Errorf("coalesced.Count(): 3 != %v\n", count)
+	}
+	now = now.Add(2 * time.Second)
+	r.Update(50)
+	if count := h.Count(); 1 != count {
+		t.Errorf("h.Count() after SnapshotAndReset: 1 != %v\n", count)
+	}
+}
+
+func TestRateLimitedHistogramInvalid(t *testing.T) {
+	if _, _, err := NewRateLimitedHistogram(MustNewHistogram(MustNewUniformSample(100)), 0); nil == err {
+		t.Error("NewRateLimitedHistogram(h, 0): no error")
+	}
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import "time"
+
+// RequestMetrics buffer the updates made while serving a single request and
+// apply them to the metrics they are for all at once, or not at all.  They
+// are not safe for concurrent use.
+type RequestMetrics interface {
+	// Apply all buffered updates and forget them.  Counters and meters are
+	// updated once with the sum of their increments and histograms once with
+	// a batch of their values.
+	Commit()
+
+	// Forget all buffered updates, for example when the request failed and
+	// should not count.
+	Discard()
+
+	// Buffer an increment of a counter.
+	Inc(c Counter, n int64)
+
+	// Buffer a mark of n events on a meter.
+	Mark(m Meter, n int64)
+
+	// Buffer a duration to record in a timer.
+	Time(t Timer, d time.Duration)
+
+	// Buffer a value to update a histogram with.
+	Update(h Histogram, v int64)
+}
+
+// The standard implementation of RequestMetrics keeps a map per kind of
+// metric.
+type requestMetrics struct {
+	counters   map[Counter]int64
+	meters     map[Meter]int64
+	histograms map[Histogram][]int64
+	timers     map[Timer][]time.Duration
+}
+
+// Create a new, empty RequestMetrics.
+func NewRequestMetrics() RequestMetrics {
+	return &requestMetrics{
+		counters:   make(map[Counter]int64),
+		meters:     make(map[Meter]int64),
+		histograms: make(map[Histogram][]int64),
+		timers:     make(map[Timer][]time.Duration),
+	}
+}
+
+func (r *requestMetrics) Commit() {
+	for c, n := range r.counters {
+		c.Inc(n)
+	}
+	for m, n := range r.meters {
+		m.Mark(n)
+	}
+	for h, vs := range r.histograms {
+		h.UpdateBatch(vs)
+	}
+	for t, ds := range r.timers {
+		for _, d := range ds {
+			t.Update(d)
+		}
+	}
+	r.Discard()
+}
+
+func (r *requestMetrics) Discard() {
+	clear(r.counters)
+	clear(r.meters)
+	clear(r.histograms)
+	clear(r.timers)
+}
+
+func (r *requestMetrics) Inc(c Counter, n int64) {
+	r.counters[c] += n
+}
+
+func (r *requestMetrics) Mark(m Meter, n int64) {
+	r.meters[m] += n
+}
+
+func (r *requestMetrics) Time(t Timer, d time.Duration) {
+	r.timers[t] = append(r.timers[t], d)
+}
+
+func (r *requestMetrics) Update(h Histogram, v int64) {
+	r.histograms[h] = append(r.histograms[h], v)
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestMetricsCommit(t *testing.T) {
+	c := NewCounter()
+	m := MustNewMeter()
+	h := MustNewHistogram(MustNewUniformSample(100))
+	tm := MustNewTimer()
+	r := NewRequestMetrics()
+	r.Inc(c, 1)
+	r.Inc(c, 2)
+	r.Mark(m, 5)
+	r.Update(h, 1)
+	r.Update(h, 3)
+	r.Time(tm, time.Second)
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+	r.Commit()
+	if count := c.Count(); 3 != count {
+		t.Errorf("c.Count(): 3 != %v\n", count)
+	}
+	if count := m.Count(); 5 != count {
+		t.Errorf("m.Count(): 5 != %v\n", count)
+	}
+	if mean := h.Mean(); 2 != mean {
+		t.Errorf("h.Mean(): 2 != %v\n", mean)
+	}
+	if count := tm.Count(); 1 != count {
+		t.Errorf("tm.Count(): 1 != %v\n", count)
+	}
+	r.Commit()
+	if count := c.Count(); 3 != count {
+		t.Errorf("c.Count() after second Commit: 3 != %v\n", count)
+	}
+}
+
+func TestRequestMetricsDiscard(t *testing.T) {
+	c := NewCounter()
+	r := NewRequestMetrics()
+	r.Inc(c, 1)
+	r.Discard()
+	r.Commit()
+	if count := c.Count(); 0 != count {
+		t.Errorf("c.Count(): 0 != %v\n", count)
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Runners tick a set of Tickables, such as meters, timers and collectors,
+// from a goroutine of their own, so that one call starts or stops all the
+// background work metrics need.
+type Runner interface {
+	// Start ticking in a new goroutine until ctx is done or Stop is called.
+	// Starting a runner which is already running does nothing.
+	Start(ctx context.Context)
+
+	// Stop ticking and wait for the goroutine to exit.  Stopping a runner
+	// which is not running does nothing.
+	Stop()
+}
+
+// The standard implementation of a Runner ticks every Tickable in turn on
+// each tick of a time.Ticker.
+type runner struct {
+	interval time.Duration
+	ts       []Tickable
+	mutex    sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// Create a new runner which ticks ts every interval, which must be positive.
+// For meters and timers it should be the interval they were created with,
+// TickDuration unless WithTickInterval said otherwise.
+func NewRunner(interval time.Duration, ts ...Tickable) (Runner, error) {
+	if interval <= 0 {
+		return nil, invalidf("interval must be positive, got %v", interval)
+	}
+	return &runner{interval: interval, ts: ts}, nil
+}
+
+// Like NewRunner but panics if the interval is invalid.
+func MustNewRunner(interval time.Duration, ts ...Tickable) Runner {
+	r, err := NewRunner(interval, ts...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *runner) Start(ctx context.Context) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.done != nil {
+		select {
+		case <-r.done:
+		default:
+			return
+		}
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	go r.run(ctx, r.done)
+}
+
+func (r *runner) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.done == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+	r.cancel, r.done = nil, nil
+}
+
+func (r *runner) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, t := range r.ts {
+				t.Tick()
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type tickCounter struct{ n atomic.Int64 }
+
+func (t *tickCounter) Tick() { t.n.Add(1) }
+
+func TestRunner(t *testing.T) {
+	tc := &tickCounter{}
+	c := NewCounter()
+	b := NewBatchedCounter(c)
+	r := MustNewRunner(time.Millisecond, tc, b)
+	b.Inc(3)
+	r.Start(context.Background())
+	r.Start(context.Background())
+	for tc.n.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	r.Stop()
+	n := tc.n.Load()
+	time.Sleep(10 * time.Millisecond)
+	if m := tc.n.Load(); n != m {
+		t.Errorf("ticks after Stop: %v != %v\n", n, m)
+	}
+	if count := c.Count(); 3 != count {
+		t.Errorf("c.Count(): 3 != %v\n", count)
+	}
+	r.Stop()
+}
+
+func TestRunnerContext(t *testing.T) {
+	tc := &tickCounter{}
+	r := MustNewRunner(time.Millisecond, tc)
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Start(ctx)
+	cancel()
+	r.Stop()
+	r.Start(context.Background())
+	for tc.n.Load() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	r.Stop()
+}
+
+func TestRunnerInvalidInterval(t *testing.T) {
+	if _, err := NewRunner(0); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewRunner(0): %v is not ErrInvalidArgument", err)
+	}
+}
@@ -10,20 +10,38 @@ import (
 
 const rescaleThreshold = 1e9 * 60 * 60
 
-// Samples maintain a statistically-significant selection of values from
-// a stream.
-type Sample interface {
+// Number is a constraint that permits any integer or floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Samples maintain a statistically-significant selection of int64 values
+// from a stream.
+type Sample = TypedSample[int64]
+
+// TypedSamples maintain a statistically-significant selection of values of
+// an arbitrary numeric type from a stream.
+type TypedSample[T Number] interface {
 	// Clear all samples.
 	Clear()
 
+	// Return the last values the sample was updated with, oldest first, or nil
+	// unless it was created with WithRecent.
+	Recent() []T
+
 	// Return the size of the sample, which is at most the reservoir size.
 	Size() int
 
 	// Update the sample with a new value.
-	Update(value int64)
+	Update(value T)
+
+	// Update the sample with several new values at once.
+	UpdateBatch(values []T)
 
 	// Return all the values in the sample.
-	Values() []int64
+	Values() []T
 }
 
 // An exponentially-decaying sample using a forward-decaying priority
@@ -31,110 +49,332 @@ type Sample interface {
 // Model for Streaming Systems".
 //
 // <http://www.research.att.com/people/Cormode_Graham/library/publications/CormodeShkapenyukSrivastavaXu09.pdf>
-type expDecaySample struct {
+type expDecaySample[T Number] struct {
 	alpha         float64
 	mutex         sync.RWMutex
+	now           func() time.Time
 	reservoirSize int
 	t0, t1        time.Time
-	values        expDecayIndividualSampleHeap
+	values        expDecayIndividualSampleHeap[T]
+	recent        ring[T]
 }
 
 // Create a new exponentially-decaying sample with the given reservoir size
-// and alpha.
-func NewExpDecaySample(reservoirSize int, alpha float64) Sample {
-	s := &expDecaySample{
+// and alpha.  Reservoir size must be positive and alpha must be in (0, 1).
+// It honors the WithClock and WithRecent options.
+func NewExpDecaySample(reservoirSize int, alpha float64, opts ...Option) (Sample, error) {
+	return NewTypedExpDecaySample[int64](reservoirSize, alpha, opts...)
+}
+
+// Like NewExpDecaySample but panics if the arguments are invalid.
+func MustNewExpDecaySample(reservoirSize int, alpha float64, opts ...Option) Sample {
+	s, err := NewExpDecaySample(reservoirSize, alpha, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Create a new exponentially-decaying sample of values of type T.  See
+// NewExpDecaySample.
+func NewTypedExpDecaySample[T Number](reservoirSize int, alpha float64, opts ...Option) (TypedSample[T], error) {
+	o := newOptions(opts)
+	if err := firstError(
+		checkReservoirSize(reservoirSize),
+		checkAlpha(alpha),
+		checkClock(o.now),
+		checkRecent(o.recent),
+	); err != nil {
+		return nil, err
+	}
+	s := &expDecaySample[T]{
 		alpha:         alpha,
+		now:           o.now,
 		reservoirSize: reservoirSize,
-		t0:            time.Now(),
-		values:        make(expDecayIndividualSampleHeap, 0, reservoirSize),
+		t0:            o.now(),
+		values:        make(expDecayIndividualSampleHeap[T], 0, reservoirSize),
+		recent:        newRing[T](o.recent),
 	}
-	s.t1 = time.Now().Add(rescaleThreshold)
-	return s
+	s.t1 = s.t0.Add(rescaleThreshold)
+	return s, nil
 }
 
-func (s *expDecaySample) Clear() {
+func (s *expDecaySample[T]) Clear() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.values = make(expDecayIndividualSampleHeap, 0, s.reservoirSize)
-	s.t0 = time.Now()
+	s.values = make(expDecayIndividualSampleHeap[T], 0, s.reservoirSize)
+	s.t0 = s.now()
 	s.t1 = s.t0.Add(rescaleThreshold)
+	s.recent.clear()
 }
 
-func (s *expDecaySample) Size() int {
+func (s *expDecaySample[T]) Recent() []T {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.recent.values()
+}
+
+func (s *expDecaySample[T]) Size() int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	return len(s.values)
 }
 
-func (s *expDecaySample) Update(v int64) {
+func (s *expDecaySample[T]) Update(v T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.update(v, s.now())
+}
+
+func (s *expDecaySample[T]) UpdateBatch(vs []T) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	t := s.now()
+	for _, v := range vs {
+		s.update(v, t)
+	}
+}
+
+// Update the sample with a value seen at t; must be called with the mutex
+// held.
+func (s *expDecaySample[T]) update(v T, t time.Time) {
+	s.recent.add(v)
 	if len(s.values) == s.reservoirSize {
 		heap.Pop(&s.values)
 	}
-	t := time.Now()
-	heap.Push(&s.values, expDecayIndividualSample{
+	heap.Push(&s.values, expDecayIndividualSample[T]{
 		k: math.Exp(t.Sub(s.t0).Seconds()*s.alpha) / rand.Float64(),
 		v: v,
 	})
 	if t.After(s.t1) {
 		values := s.values
 		t0 := s.t0
-		s.values = make(expDecayIndividualSampleHeap, 0, s.reservoirSize)
+		s.values = make(expDecayIndividualSampleHeap[T], 0, s.reservoirSize)
 		s.t0 = t
 		s.t1 = s.t0.Add(rescaleThreshold)
 		for _, v := range values {
-			v.k = v.k * math.Exp(-s.alpha*float64(s.t0.Sub(t0)))
+			v.k = v.k * math.Exp(-s.alpha*s.t0.Sub(t0).Seconds())
 			heap.Push(&s.values, v)
 		}
 	}
 }
 
-func (s *expDecaySample) Values() []int64 {
+func (s *expDecaySample[T]) Values() []T {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
-	values := make([]int64, len(s.values))
+	values := make([]T, len(s.values))
 	for i, v := range s.values {
 		values[i] = v.v
 	}
 	return values
 }
 
-type uniformSample struct {
+// An exponentially-decaying sample using Efraimidis and Spirakis' weighted
+// reservoir sampling, where a value seen at time t has weight
+// w = exp(alpha*(t-L)) for a landmark L and key u^(1/w) for a uniformly
+// random u, and the values with the largest keys are kept.  Keys are stored
+// as log(-log(u)) - alpha*(t-L), which orders the other way around and does
+// not overflow, so moving the landmark is optional.
+//
+// <https://doi.org/10.1016/j.ipl.2005.11.003>
+type weightedExpDecaySample[T Number] struct {
+	alpha         float64
+	mutex         sync.RWMutex
+	now           func() time.Time
+	reservoirSize int
+	rescale       time.Duration
+	landmark      time.Time
+	values        weightedSampleHeap[T]
+	recent        ring[T]
+}
+
+// Create a new exponentially-decaying sample like NewExpDecaySample but
+// using the textbook weighted reservoir algorithm, which keeps each value
+// with the probability its decayed weight calls for however bursty updates
+// are.  It honors the WithClock, WithLandmarkRescale and WithRecent options.
+func NewWeightedExpDecaySample(reservoirSize int, alpha float64, opts ...Option) (Sample, error) {
+	return NewTypedWeightedExpDecaySample[int64](reservoirSize, alpha, opts...)
+}
+
+// Like NewWeightedExpDecaySample but panics if the arguments are invalid.
+func MustNewWeightedExpDecaySample(reservoirSize int, alpha float64, opts ...Option) Sample {
+	s, err := NewWeightedExpDecaySample(reservoirSize, alpha, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Create a new weighted exponentially-decaying sample of values of type T.
+// See NewWeightedExpDecaySample.
+func NewTypedWeightedExpDecaySample[T Number](reservoirSize int, alpha float64, opts ...Option) (TypedSample[T], error) {
+	o := newOptions(opts)
+	if err := firstError(
+		checkReservoirSize(reservoirSize),
+		checkAlpha(alpha),
+		checkClock(o.now),
+		checkLandmarkRescale(o.landmark),
+		checkRecent(o.recent),
+	); err != nil {
+		return nil, err
+	}
+	return &weightedExpDecaySample[T]{
+		alpha:         alpha,
+		now:           o.now,
+		reservoirSize: reservoirSize,
+		rescale:       o.landmark,
+		landmark:      o.now(),
+		values:        make(weightedSampleHeap[T], 0, reservoirSize),
+		recent:        newRing[T](o.recent),
+	}, nil
+}
+
+func (s *weightedExpDecaySample[T]) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.values = make(weightedSampleHeap[T], 0, s.reservoirSize)
+	s.landmark = s.now()
+	s.recent.clear()
+}
+
+func (s *weightedExpDecaySample[T]) Recent() []T {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.recent.values()
+}
+
+func (s *weightedExpDecaySample[T]) Size() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.values)
+}
+
+func (s *weightedExpDecaySample[T]) Update(v T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.update(v, s.now())
+}
+
+func (s *weightedExpDecaySample[T]) UpdateBatch(vs []T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t := s.now()
+	for _, v := range vs {
+		s.update(v, t)
+	}
+}
+
+// Update the sample with a value seen at t; must be called with the mutex
+// held.
+func (s *weightedExpDecaySample[T]) update(v T, t time.Time) {
+	s.recent.add(v)
+	if s.rescale > 0 && t.Sub(s.landmark) >= s.rescale {
+		d := s.alpha * t.Sub(s.landmark).Seconds()
+		for i := range s.values {
+			s.values[i].k += d
+		}
+		s.landmark = t
+	}
+	// 1-u is uniform in (0, 1] so the logarithm is always defined.
+	k := math.Log(-math.Log(1-rand.Float64())) - s.alpha*t.Sub(s.landmark).Seconds()
+	if len(s.values) < s.reservoirSize {
+		heap.Push(&s.values, expDecayIndividualSample[T]{k: k, v: v})
+	} else if k < s.values[0].k {
+		s.values[0] = expDecayIndividualSample[T]{k: k, v: v}
+		heap.Fix(&s.values, 0)
+	}
+}
+
+func (s *weightedExpDecaySample[T]) Values() []T {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	values := make([]T, len(s.values))
+	for i, v := range s.values {
+		values[i] = v.v
+	}
+	return values
+}
+
+type uniformSample[T Number] struct {
 	mutex         sync.RWMutex
 	reservoirSize int
 	count         int64
-	values        []int64
+	values        []T
+	recent        ring[T]
 }
 
-// Create a new uniform sample with the given reservoir size.
+// Create a new uniform sample with the given reservoir size, which must be
+// positive.  It honors the WithRecent option.
 //
 // Sample is using Vitter's Algorithm R:
 // http://www.cs.umd.edu/~samir/498/vitter.pdf
-func NewUniformSample(reservoirSize int) Sample {
-	return &uniformSample{
-		reservoirSize: reservoirSize,
-		values:        make([]int64, 0, reservoirSize),
+func NewUniformSample(reservoirSize int, opts ...Option) (Sample, error) {
+	return NewTypedUniformSample[int64](reservoirSize, opts...)
+}
+
+// Like NewUniformSample but panics if reservoir size is invalid.
+func MustNewUniformSample(reservoirSize int, opts ...Option) Sample {
+	s, err := NewUniformSample(reservoirSize, opts...)
+	if err != nil {
+		panic(err)
 	}
+	return s
+}
+
+// Create a new uniform sample of values of type T.  See NewUniformSample.
+func NewTypedUniformSample[T Number](reservoirSize int, opts ...Option) (TypedSample[T], error) {
+	o := newOptions(opts)
+	if err := firstError(
+		checkReservoirSize(reservoirSize),
+		checkRecent(o.recent),
+	); err != nil {
+		return nil, err
+	}
+	return &uniformSample[T]{
+		reservoirSize: reservoirSize,
+		values:        make([]T, 0, reservoirSize),
+		recent:        newRing[T](o.recent),
+	}, nil
 }
 
-func (s *uniformSample) Clear() {
+func (s *uniformSample[T]) Clear() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.count = 0
-	s.values = make([]int64, 0, s.reservoirSize)
+	s.values = make([]T, 0, s.reservoirSize)
+	s.recent.clear()
+}
+
+func (s *uniformSample[T]) Recent() []T {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.recent.values()
 }
 
-func (s *uniformSample) Size() int {
+func (s *uniformSample[T]) Size() int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	return len(s.values)
 }
 
-func (s *uniformSample) Update(v int64) {
+func (s *uniformSample[T]) Update(v T) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.update(v)
+}
+
+func (s *uniformSample[T]) UpdateBatch(vs []T) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	for _, v := range vs {
+		s.update(v)
+	}
+}
+
+// Update the sample with a value; must be called with the mutex held.
+func (s *uniformSample[T]) update(v T) {
 	s.count++
+	s.recent.add(v)
 	if len(s.values) < s.reservoirSize {
 		s.values = append(s.values, v)
 	} else {
@@ -145,32 +385,74 @@ func (s *uniformSample) Update(v int64) {
 	}
 }
 
-func (s *uniformSample) Values() []int64 {
+func (s *uniformSample[T]) Values() []T {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
-	values := make([]int64, len(s.values))
+	values := make([]T, len(s.values))
 	copy(values, s.values)
 	return values
 }
 
+// A fixed-size ring buffer of the last values added to it.  The zero value
+// keeps nothing.
+type ring[T Number] struct {
+	buf  []T
+	next int
+	full bool
+}
+
+func newRing[T Number](n int) ring[T] {
+	if 0 == n {
+		return ring[T]{}
+	}
+	return ring[T]{buf: make([]T, n)}
+}
+
+func (r *ring[T]) add(v T) {
+	if 0 == len(r.buf) {
+		return
+	}
+	r.buf[r.next] = v
+	if r.next++; r.next == len(r.buf) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+func (r *ring[T]) clear() {
+	r.next = 0
+	r.full = false
+}
+
+// Return a copy of the values, oldest first.
+func (r *ring[T]) values() []T {
+	if 0 == len(r.buf) {
+		return nil
+	}
+	if !r.full {
+		return append([]T(nil), r.buf[:r.next]...)
+	}
+	return append(append(make([]T, 0, len(r.buf)), r.buf[r.next:]...), r.buf[:r.next]...)
+}
+
 // An individual sample.
-type expDecayIndividualSample struct {
+type expDecayIndividualSample[T Number] struct {
 	k float64
-	v int64
+	v T
 }
 
 // A min-heap of samples.
-type expDecayIndividualSampleHeap []expDecayIndividualSample
+type expDecayIndividualSampleHeap[T Number] []expDecayIndividualSample[T]
 
-func (q expDecayIndividualSampleHeap) Len() int {
+func (q expDecayIndividualSampleHeap[T]) Len() int {
 	return len(q)
 }
 
-func (q expDecayIndividualSampleHeap) Less(i, j int) bool {
+func (q expDecayIndividualSampleHeap[T]) Less(i, j int) bool {
 	return q[i].k < q[j].k
 }
 
-func (q *expDecayIndividualSampleHeap) Pop() interface{} {
+func (q *expDecayIndividualSampleHeap[T]) Pop() interface{} {
 	q_ := *q
 	n := len(q_)
 	i := q_[n-1]
@@ -179,14 +461,41 @@ func (q *expDecayIndividualSampleHeap) Pop() interface{} {
 	return i
 }
 
-func (q *expDecayIndividualSampleHeap) Push(x interface{}) {
+func (q *expDecayIndividualSampleHeap[T]) Push(x interface{}) {
 	q_ := *q
 	n := len(q_)
 	q_ = q_[0 : n+1]
-	q_[n] = x.(expDecayIndividualSample)
+	q_[n] = x.(expDecayIndividualSample[T])
 	*q = q_
 }
 
-func (q expDecayIndividualSampleHeap) Swap(i, j int) {
+func (q expDecayIndividualSampleHeap[T]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+// A max-heap of weighted samples, so the one to evict is at the root.
+type weightedSampleHeap[T Number] []expDecayIndividualSample[T]
+
+func (q weightedSampleHeap[T]) Len() int {
+	return len(q)
+}
+
+func (q weightedSampleHeap[T]) Less(i, j int) bool {
+	return q[i].k > q[j].k
+}
+
+func (q *weightedSampleHeap[T]) Pop() interface{} {
+	q_ := *q
+	n := len(q_)
+	i := q_[n-1]
+	*q = q_[0 : n-1]
+	return i
+}
+
+func (q *weightedSampleHeap[T]) Push(x interface{}) {
+	*q = append(*q, x.(expDecayIndividualSample[T]))
+}
+
+func (q weightedSampleHeap[T]) Swap(i, j int) {
 	q[i], q[j] = q[j], q[i]
 }
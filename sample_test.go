@@ -1,38 +1,52 @@
 package metrics
 
 import (
+	"errors"
 	"math/rand"
 	"runtime"
+	"slices"
 	"testing"
 	"time"
 )
 
 func BenchmarkExpDecaySample257(b *testing.B) {
-	benchmarkSample(b, NewExpDecaySample(257, 0.015))
+	benchmarkSample(b, MustNewExpDecaySample(257, 0.015))
 }
 
 func BenchmarkExpDecaySample514(b *testing.B) {
-	benchmarkSample(b, NewExpDecaySample(514, 0.015))
+	benchmarkSample(b, MustNewExpDecaySample(514, 0.015))
 }
 
 func BenchmarkExpDecaySample1028(b *testing.B) {
-	benchmarkSample(b, NewExpDecaySample(1028, 0.015))
+	benchmarkSample(b, MustNewExpDecaySample(1028, 0.015))
 }
 
 func BenchmarkUniformSample257(b *testing.B) {
-	benchmarkSample(b, NewUniformSample(257))
+	benchmarkSample(b, MustNewUniformSample(257))
 }
 
 func BenchmarkUniformSample514(b *testing.B) {
-	benchmarkSample(b, NewUniformSample(514))
+	benchmarkSample(b, MustNewUniformSample(514))
 }
 
 func BenchmarkUniformSample1028(b *testing.B) {
-	benchmarkSample(b, NewUniformSample(1028))
+	benchmarkSample(b, MustNewUniformSample(1028))
+}
+
+func BenchmarkWeightedExpDecaySample257(b *testing.B) {
+	benchmarkSample(b, MustNewWeightedExpDecaySample(257, 0.015))
+}
+
+func BenchmarkWeightedExpDecaySample514(b *testing.B) {
+	benchmarkSample(b, MustNewWeightedExpDecaySample(514, 0.015))
+}
+
+func BenchmarkWeightedExpDecaySample1028(b *testing.B) {
+	benchmarkSample(b, MustNewWeightedExpDecaySample(1028, 0.015))
 }
 
 func TestExpDecaySample10(t *testing.T) {
-	s := NewExpDecaySample(100, 0.99)
+	s := MustNewExpDecaySample(100, 0.99)
 	for i := 0; i < 10; i++ {
 		s.Update(int64(i))
 	}
@@ -50,7 +64,7 @@ func TestExpDecaySample10(t *testing.T) {
 }
 
 func TestExpDecaySample100(t *testing.T) {
-	s := NewExpDecaySample(1000, 0.01)
+	s := MustNewExpDecaySample(1000, 0.01)
 	for i := 0; i < 100; i++ {
 		s.Update(int64(i))
 	}
@@ -68,7 +82,7 @@ func TestExpDecaySample100(t *testing.T) {
 }
 
 func TestExpDecaySample1000(t *testing.T) {
-	s := NewExpDecaySample(100, 0.99)
+	s := MustNewExpDecaySample(100, 0.99)
 	for i := 0; i < 1000; i++ {
 		s.Update(int64(i))
 	}
@@ -90,7 +104,7 @@ func TestExpDecaySample1000(t *testing.T) {
 // The priority becomes +Inf quickly after starting if this is done,
 // effectively freezing the set of samples until a rescale step happens.
 func TestExpDecaySampleNanosecondRegression(t *testing.T) {
-	s := NewExpDecaySample(100, 0.99)
+	s := MustNewExpDecaySample(100, 0.99)
 
 	for i := 0; i < 100; i++ {
 		s.Update(10)
@@ -115,8 +129,23 @@ func TestExpDecaySampleNanosecondRegression(t *testing.T) {
 	}
 }
 
+// Rescaling must decay keys by the seconds between landmarks; decaying them
+// by nanoseconds underflows every key to zero.
+func TestExpDecaySampleRescale(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := MustNewExpDecaySample(100, 0.015, WithClock(func() time.Time { return now }))
+	s.Update(1)
+	now = now.Add(time.Hour + time.Second)
+	s.Update(2)
+	for _, v := range s.(*expDecaySample[int64]).values {
+		if 0 == v.k {
+			t.Errorf("key of %v after rescale: 0\n", v.v)
+		}
+	}
+}
+
 func TestUniformSample(t *testing.T) {
-	s := NewUniformSample(100)
+	s := MustNewUniformSample(100)
 	for i := 0; i < 1000; i++ {
 		s.Update(int64(i))
 	}
@@ -134,7 +163,7 @@ func TestUniformSample(t *testing.T) {
 }
 
 func TestUniformSampleIncludesTail(t *testing.T) {
-	s := NewUniformSample(100)
+	s := MustNewUniformSample(100)
 	max := 100
 
 	for i := 0; i < max; i++ {
@@ -177,7 +206,7 @@ func TestHistogramConcurrentUpdateCount(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping in short mode")
 	}
-	h := NewHistogram(NewUniformSample(100))
+	h := MustNewHistogram(MustNewUniformSample(100))
 	for i := 0; i < 100; i++ {
 		h.Update(int64(i))
 	}
@@ -203,3 +232,175 @@ func TestHistogramConcurrentUpdateCount(t *testing.T) {
 	}
 	quit <- struct{}{}
 }
+
+func TestSampleInvalidArguments(t *testing.T) {
+	if _, err := NewUniformSample(0); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewUniformSample(0): %v is not ErrInvalidArgument", err)
+	} else if "metrics: reservoir size must be positive, got 0" != err.Error() {
+		t.Errorf("err.Error(): %q\n", err.Error())
+	}
+	if _, err := NewExpDecaySample(0, 0.015); err == nil {
+		t.Error("NewExpDecaySample(0, 0.015): expected error")
+	}
+	if _, err := NewExpDecaySample(100, 1); err == nil {
+		t.Error("NewExpDecaySample(100, 1): expected error")
+	}
+	if _, err := NewUniformSample(100, WithRecent(-1)); err == nil {
+		t.Error("NewUniformSample(100, WithRecent(-1)): expected error")
+	}
+}
+
+func TestSampleUpdateBatch(t *testing.T) {
+	for _, s := range []Sample{
+		MustNewUniformSample(100),
+		MustNewExpDecaySample(100, 0.015),
+	} {
+		s.UpdateBatch([]int64{1, 2, 3})
+		s.Update(4)
+		if size := s.Size(); 4 != size {
+			t.Errorf("s.Size(): 4 != %v\n", size)
+		}
+	}
+}
+
+func TestSampleRecent(t *testing.T) {
+	for _, s := range []Sample{
+		MustNewUniformSample(2, WithRecent(3)),
+		MustNewExpDecaySample(2, 0.015, WithRecent(3)),
+	} {
+		s.Update(1)
+		s.Update(2)
+		if r := s.Recent(); !slices.Equal([]int64{1, 2}, r) {
+			t.Errorf("s.Recent(): [1 2] != %v\n", r)
+		}
+		s.UpdateBatch([]int64{3, 4, 5, 6})
+		if r := s.Recent(); !slices.Equal([]int64{4, 5, 6}, r) {
+			t.Errorf("s.Recent(): [4 5 6] != %v\n", r)
+		}
+		h := MustNewHistogram(s)
+		h.Update(7)
+		if r := h.SnapshotAndReset().Recent(); !slices.Equal([]int64{5, 6, 7}, r) {
+			t.Errorf("h.SnapshotAndReset().Recent(): [5 6 7] != %v\n", r)
+		}
+		if r := s.Recent(); 0 != len(r) {
+			t.Errorf("s.Recent(): [] != %v\n", r)
+		}
+	}
+	if r := MustNewUniformSample(2).Recent(); nil != r {
+		t.Errorf("s.Recent(): nil != %v\n", r)
+	}
+}
+
+func TestWeightedExpDecaySampleUniformWhenAlphaIsTiny(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping distribution test in short mode")
+	}
+	// With no decay to speak of every value should be kept equally often.
+	const trials, n, size = 2000, 1000, 100
+	var deciles [10]int
+	for i := 0; i < trials; i++ {
+		s := MustNewWeightedExpDecaySample(size, 1e-12)
+		for v := 0; v < n; v++ {
+			s.Update(int64(v))
+		}
+		for _, v := range s.Values() {
+			deciles[v*10/n]++
+		}
+	}
+	const expected = trials * size / 10
+	for i, c := range deciles {
+		if c < expected*95/100 || c > expected*105/100 {
+			t.Errorf("decile %d: %v not within 5%% of %v\n", i, c, expected)
+		}
+	}
+}
+
+func TestWeightedExpDecaySampleFavorsRecentValues(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping distribution test in short mode")
+	}
+	// A burst of old values must not crowd out a trickle of new ones: with
+	// alpha 0.015 a value a minute newer weighs about 2.46 times as much.
+	const trials, size = 500, 100
+	var old, young int
+	for i := 0; i < trials; i++ {
+		now := time.Unix(0, 0)
+		s := MustNewWeightedExpDecaySample(size, 0.015, WithClock(func() time.Time { return now }))
+		for v := 0; v < 1000; v++ {
+			s.Update(0)
+		}
+		now = now.Add(time.Minute)
+		for v := 0; v < 1000; v++ {
+			s.Update(1)
+		}
+		for _, v := range s.Values() {
+			if 0 == v {
+				old++
+			} else {
+				young++
+			}
+		}
+	}
+	ratio := float64(young) / float64(old)
+	if ratio < 2.2 || ratio > 2.8 {
+		t.Errorf("young/old: %v not about 2.46\n", ratio)
+	}
+}
+
+func TestWeightedExpDecaySampleLandmarkRescale(t *testing.T) {
+	// An hour between updates makes each value outweigh the previous one by
+	// a factor of e^54, so only the newest values are kept however the
+	// landmark is handled.  Without moving it, weights reach e^5400, which
+	// would overflow unless kept in log form.
+	expected := []int64{90, 91, 92, 93, 94, 95, 96, 97, 98, 99}
+	for _, d := range []time.Duration{0, 5 * time.Minute, 10 * time.Hour} {
+		now := time.Unix(0, 0)
+		s := MustNewWeightedExpDecaySample(10, 0.015, WithClock(func() time.Time { return now }), WithLandmarkRescale(d))
+		for v := 0; v < 100; v++ {
+			now = now.Add(time.Hour)
+			s.Update(int64(v))
+		}
+		vs := s.Values()
+		slices.Sort(vs)
+		if !slices.Equal(expected, vs) {
+			t.Errorf("WithLandmarkRescale(%v): %v != %v\n", d, expected, vs)
+		}
+	}
+	if _, err := NewWeightedExpDecaySample(10, 0.015, WithLandmarkRescale(-1)); err == nil {
+		t.Error("NewWeightedExpDecaySample(10, 0.015, WithLandmarkRescale(-1)): expected error")
+	}
+}
+
+func TestExpDecaySamplesAgreeOnSteadyStreams(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping distribution test in short mode")
+	}
+	// Both samples should keep about the same share of each decile of a
+	// steady stream, which with a slow decay spans a rescale of the keys.
+	const trials, n, size, alpha = 500, 1440, 100, 0.0005
+	deciles := func(newSample func(now func() time.Time) Sample) (d [10]int) {
+		for i := 0; i < trials; i++ {
+			now := time.Unix(0, 0)
+			s := newSample(func() time.Time { return now })
+			for v := 0; v < n; v++ {
+				now = now.Add(5 * time.Second)
+				s.Update(int64(v))
+			}
+			for _, v := range s.Values() {
+				d[v*10/n]++
+			}
+		}
+		return d
+	}
+	priority := deciles(func(now func() time.Time) Sample {
+		return MustNewExpDecaySample(size, alpha, WithClock(now))
+	})
+	weighted := deciles(func(now func() time.Time) Sample {
+		return MustNewWeightedExpDecaySample(size, alpha, WithClock(now))
+	})
+	for i := range priority {
+		if p, w := priority[i], weighted[i]; p < w*75/100 || p > w*125/100 {
+			t.Errorf("decile %d: %v not within 25%% of %v\n", i, p, w)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"math"
+	rtmetrics "runtime/metrics"
+	"slices"
+	"sync"
+)
+
+// SchedulerCollectors expose Go scheduler latency and CPU saturation read
+// from runtime/metrics as gauges.  Tick reads current values into the
+// gauges; latency and saturation describe the time since the previous Tick.
+type SchedulerCollector interface {
+	Tickable
+
+	// Return the gauge of the given percentile of the time goroutines spent
+	// runnable before running, in nanoseconds, or nil if the percentile was
+	// not requested on creation.
+	Latency(p float64) Gauge
+
+	// Return the gauge of goroutines ready to run but not running.  It stays
+	// at zero on Go versions which do not report it.
+	RunQueue() Gauge
+
+	// Return the gauge of the share of available CPU time, in percent, spent
+	// running Go code and the runtime.  Available CPU time is derived from
+	// GOMAXPROCS, which follows the cgroup CPU quota on recent Go versions.
+	Saturation() Gauge
+}
+
+const (
+	schedLatencies = "/sched/latencies:seconds"
+	schedRunnable  = "/sched/goroutines/runnable:goroutines"
+	cpuTotal       = "/cpu/classes/total:cpu-seconds"
+	cpuIdle        = "/cpu/classes/idle:cpu-seconds"
+)
+
+// The standard implementation of a SchedulerCollector keeps the previous
+// readings of cumulative runtime metrics to compute per-tick values.
+type schedulerCollector struct {
+	mutex      sync.Mutex
+	samples    []rtmetrics.Sample
+	ps         []float64
+	latencies  []Gauge
+	runQueue   Gauge
+	saturation Gauge
+	counts     []uint64
+	total      float64
+	idle       float64
+}
+
+// Create a new scheduler collector reporting the given latency percentiles,
+// each of which must be in (0, 1].
+func NewSchedulerCollector(ps []float64) (SchedulerCollector, error) {
+	for _, p := range ps {
+		if !(p > 0 && p <= 1) {
+			return nil, invalidf("percentile must be in (0, 1], got %v", p)
+		}
+	}
+	c := &schedulerCollector{
+		samples: []rtmetrics.Sample{
+			{Name: schedLatencies},
+			{Name: schedRunnable},
+			{Name: cpuTotal},
+			{Name: cpuIdle},
+		},
+		ps:         slices.Clone(ps),
+		latencies:  make([]Gauge, len(ps)),
+		runQueue:   NewGauge(),
+		saturation: NewGauge(),
+	}
+	for i := range c.latencies {
+		c.latencies[i] = NewGauge()
+	}
+	return c, nil
+}
+
+// Like NewSchedulerCollector but panics if a percentile is invalid.
+func MustNewSchedulerCollector(ps []float64) SchedulerCollector {
+	c, err := NewSchedulerCollector(ps)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *schedulerCollector) Latency(p float64) Gauge {
+	if i := slices.Index(c.ps, p); i >= 0 {
+		return c.latencies[i]
+	}
+	return nil
+}
+
+func (c *schedulerCollector) RunQueue() Gauge {
+	return c.runQueue
+}
+
+func (c *schedulerCollector) Saturation() Gauge {
+	return c.saturation
+}
+
+func (c *schedulerCollector) Tick() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	rtmetrics.Read(c.samples)
+	if v := c.samples[0].Value; v.Kind() == rtmetrics.KindFloat64Histogram {
+		h := v.Float64Histogram()
+		deltas := slices.Clone(h.Counts)
+		var total uint64
+		for i := range deltas {
+			if i < len(c.counts) {
+				deltas[i] -= c.counts[i]
+			}
+			total += deltas[i]
+		}
+		if total > 0 {
+			for i, p := range c.ps {
+				s := bucketPercentile(h.Buckets, deltas, total, p)
+				c.latencies[i].Update(int64(s * 1e9))
+			}
+		}
+		c.counts = slices.Clone(h.Counts)
+	}
+	if v := c.samples[1].Value; v.Kind() == rtmetrics.KindUint64 {
+		c.runQueue.Update(int64(v.Uint64()))
+	}
+	total, idle := c.samples[2].Value, c.samples[3].Value
+	if total.Kind() == rtmetrics.KindFloat64 && idle.Kind() == rtmetrics.KindFloat64 {
+		dt := total.Float64() - c.total
+		di := idle.Float64() - c.idle
+		if dt > 0 {
+			c.saturation.Update(int64(math.Round(100 * (dt - di) / dt)))
+		}
+		c.total, c.idle = total.Float64(), idle.Float64()
+	}
+}
+
+// Return an estimate of percentile p of a bucketed distribution, using the
+// upper bound of the bucket it falls into, or the lower bound if the upper
+// one is infinite.
+func bucketPercentile(buckets []float64, counts []uint64, total uint64, p float64) float64 {
+	target := p * float64(total)
+	var n uint64
+	for i, count := range counts {
+		n += count
+		if float64(n) >= target {
+			if math.IsInf(buckets[i+1], 1) {
+				return buckets[i]
+			}
+			return buckets[i+1]
+		}
+	}
+	return 0
+}
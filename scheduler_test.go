@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestBucketPercentile(t *testing.T) {
+	buckets := []float64{math.Inf(-1), 1, 2, 3, math.Inf(1)}
+	counts := []uint64{0, 5, 4, 1}
+	if v := bucketPercentile(buckets, counts, 10, 0.5); 2 != v {
+		t.Errorf("median: 2 != %v\n", v)
+	}
+	if v := bucketPercentile(buckets, counts, 10, 0.9); 3 != v {
+		t.Errorf("90th percentile: 3 != %v\n", v)
+	}
+	if v := bucketPercentile(buckets, counts, 10, 1); 3 != v {
+		t.Errorf("100th percentile: 3 != %v\n", v)
+	}
+}
+
+func TestSchedulerCollector(t *testing.T) {
+	if _, err := NewSchedulerCollector([]float64{0}); err == nil {
+		t.Error("NewSchedulerCollector([0]): expected error")
+	}
+	c := MustNewSchedulerCollector([]float64{0.5, 0.99})
+	c.Tick()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			x := 0
+			for j := 0; j < 100000; j++ {
+				x += j
+			}
+			_ = x
+		}()
+	}
+	wg.Wait()
+	c.Tick()
+	if g := c.Latency(0.9); nil != g {
+		t.Errorf("c.Latency(0.9): nil != %v\n", g)
+	}
+	if v := c.Latency(0.99).Value(); v < 0 {
+		t.Errorf("c.Latency(0.99).Value(): %v < 0\n", v)
+	}
+	if v := c.Saturation().Value(); v < 0 || v > 100 {
+		t.Errorf("c.Saturation().Value(): %v out of [0, 100]\n", v)
+	}
+}
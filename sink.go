@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"time"
+)
+
+// MetricSinks create metrics for code which should not depend on how they are
+// kept, such as a library handed a sink by the application using it.  Names
+// let sinks which export metrics tell them apart; the standard sink ignores
+// them.
+type MetricSink interface {
+	// Return a counter with the given name.
+	NewCounter(name string) Counter
+
+	// Return a gauge with the given name.
+	NewGauge(name string) Gauge
+
+	// Return a timer with the given name.
+	NewTimer(name string) Timer
+}
+
+// The standard implementation of a MetricSink creates a new standard metric
+// on every call, passing it the sink's options.
+type metricSink struct {
+	opts []Option
+}
+
+// Create a new sink of standard metrics.  Options are passed on to every
+// metric it creates, and are checked here so that creating a metric can not
+// fail later.
+func NewMetricSink(opts ...Option) (MetricSink, error) {
+	if _, err := NewTimer(opts...); err != nil {
+		return nil, err
+	}
+	return &metricSink{opts: opts}, nil
+}
+
+// Like NewMetricSink but panics if the options are invalid.
+func MustNewMetricSink(opts ...Option) MetricSink {
+	s, err := NewMetricSink(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *metricSink) NewCounter(string) Counter {
+	return NewCounter(s.opts...)
+}
+
+func (s *metricSink) NewGauge(string) Gauge {
+	return NewGauge(s.opts...)
+}
+
+func (s *metricSink) NewTimer(string) Timer {
+	return MustNewTimer(s.opts...)
+}
+
+// Create a new sink whose metrics discard updates and always read zero, for
+// applications which do not want a library's metrics.
+func NewNopMetricSink() MetricSink {
+	return nopMetricSink{}
+}
+
+// A MetricSink which hands out shared no-op metrics.
+type nopMetricSink struct{}
+
+func (nopMetricSink) NewCounter(string) Counter { return nopCounter{} }
+
+func (nopMetricSink) NewGauge(string) Gauge { return nopGauge{} }
+
+func (nopMetricSink) NewTimer(string) Timer { return nopTimer{} }
+
+// A Counter which discards updates.  Snapshots are the counter itself, so
+// they do not panic on updates either.
+type nopCounter struct{}
+
+func (nopCounter) Clear() {}
+
+func (nopCounter) Count() int64 { return 0 }
+
+func (nopCounter) Created() time.Time { return time.Time{} }
+
+func (c nopCounter) Dec(int64) Counter { return c }
+
+func (c nopCounter) Inc(int64) Counter { return c }
+
+func (nopCounter) LastUpdate() time.Time { return time.Time{} }
+
+func (c nopCounter) SnapshotAndReset() Counter { return c }
+
+func (c nopCounter) String() string { return formatCounter(c) }
+
+func (c nopCounter) MarshalJSON() ([]byte, error) { return marshalCounter(c) }
+
+func (c nopCounter) MarshalText() ([]byte, error) { return []byte(formatCounter(c)), nil }
+
+// A Gauge which discards updates.
+type nopGauge struct{}
+
+func (nopGauge) Created() time.Time { return time.Time{} }
+
+func (nopGauge) LastUpdate() time.Time { return time.Time{} }
+
+func (nopGauge) Update(int64) {}
+
+func (nopGauge) Value() int64 { return 0 }
+
+func (g nopGauge) String() string { return formatGauge(g) }
+
+func (g nopGauge) MarshalJSON() ([]byte, error) { return marshalGauge(g) }
+
+func (g nopGauge) MarshalText() ([]byte, error) { return []byte(formatGauge(g)), nil }
+
+// A Timer which discards updates.  It is its own snapshot and its own
+// capture, whose Stop does nothing.
+type nopTimer struct{}
+
+func (nopTimer) Count() int64 { return 0 }
+
+func (nopTimer) Created() time.Time { return time.Time{} }
+
+func (nopTimer) InterArrival() Histogram { return nil }
+
+func (nopTimer) Invalid() int64 { return 0 }
+
+func (nopTimer) LastUpdate() time.Time { return time.Time{} }
+
+func (nopTimer) Max() int64 { return 0 }
+
+func (nopTimer) Mean() float64 { return 0 }
+
+func (nopTimer) Min() int64 { return 0 }
+
+func (nopTimer) Percentile(float64) float64 { return 0 }
+
+func (nopTimer) Percentiles(ps []float64) []float64 { return make([]float64, len(ps)) }
+
+func (nopTimer) PercentileRank(time.Duration) float64 { return 0 }
+
+func (nopTimer) Pause() {}
+
+func (nopTimer) Resume() {}
+
+func (nopTimer) Rate1() float64 { return 0 }
+
+func (nopTimer) Rate5() float64 { return 0 }
+
+func (nopTimer) Rate15() float64 { return 0 }
+
+func (nopTimer) RateMean() float64 { return 0 }
+
+func (nopTimer) Skewed() int64 { return 0 }
+
+func (t nopTimer) SnapshotAndReset() Timer { return t }
+
+func (nopTimer) StdDev() float64 { return 0 }
+
+func (t nopTimer) Start() interface {
+	Stop()
+} {
+	return t
+}
+
+func (nopTimer) Stop() {}
+
+func (nopTimer) Update(time.Duration) {}
+
+func (nopTimer) UpdateSince(time.Time) {}
+
+func (nopTimer) Tick() {}
+
+func (t nopTimer) String() string { return formatTimer(t) }
+
+func (t nopTimer) MarshalJSON() ([]byte, error) { return marshalTimer(t) }
+
+func (t nopTimer) MarshalText() ([]byte, error) { return []byte(formatTimer(t)), nil }
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMetricSink(t *testing.T) {
+	s := MustNewMetricSink()
+	c := s.NewCounter("requests")
+	c.Inc(2)
+	if n := c.Count(); 2 != n {
+		t.Errorf("c.Count(): 2 != %v\n", n)
+	}
+	if other := s.NewCounter("requests"); 0 != other.Count() {
+		t.Errorf("s.NewCounter() shares counters: 0 != %v\n", other.Count())
+	}
+	g := s.NewGauge("in-flight")
+	g.Update(3)
+	if v := g.Value(); 3 != v {
+		t.Errorf("g.Value(): 3 != %v\n", v)
+	}
+	tm := s.NewTimer("latency")
+	tm.Update(time.Millisecond)
+	if n := tm.Count(); 1 != n {
+		t.Errorf("tm.Count(): 1 != %v\n", n)
+	}
+}
+
+func TestMetricSinkInvalidOptions(t *testing.T) {
+	if _, err := NewMetricSink(WithUnit(0)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewMetricSink(WithUnit(0)): %v is not ErrInvalidArgument", err)
+	}
+}
+
+func TestNopMetricSink(t *testing.T) {
+	s := NewNopMetricSink()
+	c := s.NewCounter("requests")
+	c.Inc(2)
+	if n := c.SnapshotAndReset().Inc(1).Count(); 0 != n {
+		t.Errorf("c.Count(): 0 != %v\n", n)
+	}
+	g := s.NewGauge("in-flight")
+	g.Update(3)
+	if v := g.Value(); 0 != v {
+		t.Errorf("g.Value(): 0 != %v\n", v)
+	}
+	tm := s.NewTimer("latency")
+	tm.Start().Stop()
+	tm.Update(time.Millisecond)
+	if n := tm.SnapshotAndReset().Count(); 0 != n {
+		t.Errorf("tm.Count(): 0 != %v\n", n)
+	}
+	if ps := tm.Percentiles([]float64{0.5, 0.99}); 2 != len(ps) {
+		t.Errorf("len(tm.Percentiles()): 2 != %v\n", len(ps))
+	}
+	if s := tm.(interface{ String() string }).String(); "" == s {
+		t.Error("tm.String(): empty")
+	}
+}
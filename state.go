@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// StateGauges hold an enumerated state, or a boolean stored as 0 or 1, and
+// track when and how often it changed.
+type StateGauge interface {
+	// Return how long the gauge has been in its current state.
+	Duration() time.Duration
+
+	// Return the time of the last transition, or the creation time if there
+	// was none.
+	LastTransition() time.Time
+
+	// Return the number of transitions.
+	Transitions() int64
+
+	// Set the current state.  Setting the state the gauge is already in is not
+	// a transition.
+	Update(state int64)
+
+	// Set the current state to 1 if b is true and to 0 otherwise.
+	UpdateBool(b bool)
+
+	// Return the current state.
+	Value() int64
+}
+
+// The standard implementation of a StateGauge uses a mutex to keep the
+// state and its transition bookkeeping consistent.
+type stateGauge struct {
+	mutex       sync.Mutex
+	now         func() time.Time
+	state       int64
+	since       time.Time
+	transitions int64
+}
+
+// Create a new state gauge in state 0.  It honors the WithClock option.
+func NewStateGauge(opts ...Option) (StateGauge, error) {
+	o := newOptions(opts)
+	if err := checkClock(o.now); err != nil {
+		return nil, err
+	}
+	return &stateGauge{now: o.now, since: o.now()}, nil
+}
+
+// Like NewStateGauge but panics if the options are invalid.
+func MustNewStateGauge(opts ...Option) StateGauge {
+	g, err := NewStateGauge(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+func (g *stateGauge) Duration() time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.now().Sub(g.since)
+}
+
+func (g *stateGauge) LastTransition() time.Time {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.since
+}
+
+func (g *stateGauge) Transitions() int64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.transitions
+}
+
+func (g *stateGauge) Update(state int64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if state == g.state {
+		return
+	}
+	g.state = state
+	g.since = g.now()
+	g.transitions++
+}
+
+func (g *stateGauge) UpdateBool(b bool) {
+	if b {
+		g.Update(1)
+	} else {
+		g.Update(0)
+	}
+}
+
+func (g *stateGauge) Value() int64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.state
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateGauge(t *testing.T) {
+	now := time.Unix(0, 0)
+	g := MustNewStateGauge(WithClock(func() time.Time { return now }))
+	now = now.Add(time.Minute)
+	g.UpdateBool(true)
+	g.UpdateBool(true)
+	now = now.Add(time.Second)
+	if v := g.Value(); 1 != v {
+		t.Errorf("g.Value(): 1 != %v\n", v)
+	}
+	if n := g.Transitions(); 1 != n {
+		t.Errorf("g.Transitions(): 1 != %v\n", n)
+	}
+	if d := g.Duration(); time.Second != d {
+		t.Errorf("g.Duration(): %v != %v\n", time.Second, d)
+	}
+	if ts := g.LastTransition(); !ts.Equal(time.Unix(60, 0)) {
+		t.Errorf("g.LastTransition(): %v != %v\n", time.Unix(60, 0), ts)
+	}
+	g.Update(2)
+	if n := g.Transitions(); 2 != n {
+		t.Errorf("g.Transitions(): 2 != %v\n", n)
+	}
+	if d := g.Duration(); 0 != d {
+		t.Errorf("g.Duration(): 0 != %v\n", d)
+	}
+}
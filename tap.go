@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TapHistograms pass updates on to another histogram and write them to an
+// io.Writer in the background.  They do not rotate files: the header goes to
+// the writer once and every row after it, so a long-running tap needs a
+// writer which rotates files itself, and files after the first lack the
+// header.
+type TapHistogram interface {
+	Histogram
+
+	// Write the rows still buffered, stop the background writer and return
+	// the first error writing to the io.Writer, if any.  Later updates are
+	// passed on but not written.
+	Close() error
+}
+
+// How many rows a tap histogram buffers for its writer before it drops them.
+const tapBuffer = 4096
+
+// How often a tap histogram flushes the rows it has written.
+const tapFlushInterval = time.Second
+
+// A row of a tap histogram.
+type tapRow struct {
+	t time.Time
+	v int64
+}
+
+// A histogram which hands updates to a background goroutine writing them to
+// a CSV file before passing them on to another histogram, so raw
+// observations can be analysed offline without blocking updates on I/O.
+type tapHistogram struct {
+	Histogram
+	dropped Counter
+	now     func() time.Time
+	n       int64 // write one in n updates
+	rows    chan tapRow
+	done    chan struct{}
+	err     error // set by the writer before it closes done
+
+	mutex  sync.RWMutex // guards closed and sending on rows
+	closed bool
+}
+
+// Create a new histogram which updates h and writes a row with the time,
+// value and label values of each update to w.  The first row written is a
+// header naming the columns: timestamp, value and the label keys in sorted
+// order.  Rows are buffered and written by a background goroutine, which
+// flushes them to w every second.  Rows which do not fit in the buffer, and
+// all rows once a write to w fails, are dropped and counted in the returned
+// counter.  Close the histogram to write the remaining rows.  To tap a
+// Timer, use NewTapTimer.  It honors the WithClock and WithTapSampling
+// options.
+func NewTapHistogram(h Histogram, w io.Writer, labels map[string]string, opts ...Option) (TapHistogram, Counter, error) {
+	o := newOptions(opts)
+	if err := checkClock(o.now); err != nil {
+		return nil, nil, err
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = labels[k]
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"timestamp", "value"}, keys...))
+	if cw.Flush(); cw.Error() != nil {
+		return nil, nil, cw.Error()
+	}
+	t := &tapHistogram{
+		Histogram: h,
+		dropped:   NewCounter(),
+		now:       o.now,
+		n:         max(int64(o.tapN), 1),
+		rows:      make(chan tapRow, tapBuffer),
+		done:      make(chan struct{}),
+	}
+	go t.write(cw, values)
+	return t, t.dropped, nil
+}
+
+// Like NewTapHistogram but panics if the options are invalid or the header
+// can not be written.
+func MustNewTapHistogram(h Histogram, w io.Writer, labels map[string]string, opts ...Option) (TapHistogram, Counter) {
+	t, c, err := NewTapHistogram(h, w, labels, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return t, c
+}
+
+func (t *tapHistogram) Close() error {
+	t.mutex.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.rows)
+	}
+	t.mutex.Unlock()
+	<-t.done
+	return t.err
+}
+
+func (t *tapHistogram) Update(v int64) {
+	t.tap(t.now(), v)
+	t.Histogram.Update(v)
+}
+
+func (t *tapHistogram) UpdateBatch(vs []int64) {
+	now := t.now()
+	for _, v := range vs {
+		t.tap(now, v)
+	}
+	t.Histogram.UpdateBatch(vs)
+}
+
+// Hand a row to the writer unless it is sampled out, dropping it if the
+// buffer is full.
+func (t *tapHistogram) tap(now time.Time, v int64) {
+	if t.n > 1 && 0 != rand.Int64N(t.n) {
+		return
+	}
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.rows <- tapRow{now, v}:
+	default:
+		t.dropped.Inc(1)
+	}
+}
+
+// Write rows to w until the histogram is closed, flushing every
+// tapFlushInterval.
+func (t *tapHistogram) write(w *csv.Writer, labels []string) {
+	defer close(t.done)
+	ticker := time.NewTicker(tapFlushInterval)
+	defer ticker.Stop()
+	record := make([]string, 2, 2+len(labels))
+	record = append(record, labels...)
+	unflushed := int64(0)
+	flush := func() {
+		if 0 == unflushed || t.err != nil {
+			return
+		}
+		if w.Flush(); w.Error() != nil {
+			t.err = w.Error()
+			t.dropped.Inc(unflushed)
+		}
+		unflushed = 0
+	}
+	for {
+		select {
+		case row, ok := <-t.rows:
+			if !ok {
+				flush()
+				return
+			}
+			if t.err != nil {
+				t.dropped.Inc(1)
+				continue
+			}
+			record[0] = row.t.Format(time.RFC3339Nano)
+			record[1] = strconv.FormatInt(row.v, 10)
+			if err := w.Write(record); err != nil {
+				t.err = err
+				t.dropped.Inc(unflushed + 1)
+				unflushed = 0
+				continue
+			}
+			unflushed++
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// TapTimers are timers whose histogram is a TapHistogram.
+type TapTimer interface {
+	Timer
+
+	// Close the timer's tap.  See TapHistogram.
+	Close() error
+}
+
+// A standard timer whose histogram is wrapped in a tap.
+type tapTimer struct {
+	*timer
+	tap TapHistogram
+}
+
+// Create a new timer like NewTimer whose histogram updates are written to w
+// like NewTapHistogram does, in the timer's unit.  Durations the timer
+// drops under its InvalidPolicy are not written.  Options are passed on to
+// both.
+func NewTapTimer(w io.Writer, labels map[string]string, opts ...Option) (TapTimer, Counter, error) {
+	t, err := NewTimer(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	tm := t.(*timer)
+	tap, dropped, err := NewTapHistogram(tm.h, w, labels, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	tm.h = tap
+	return &tapTimer{timer: tm, tap: tap}, dropped, nil
+}
+
+// Like NewTapTimer but panics if the options are invalid or the header can
+// not be written.
+func MustNewTapTimer(w io.Writer, labels map[string]string, opts ...Option) (TapTimer, Counter) {
+	t, c, err := NewTapTimer(w, labels, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return t, c
+}
+
+func (t *tapTimer) Close() error {
+	return t.tap.Close()
+}
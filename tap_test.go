@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTapHistogram(t *testing.T) {
+	var b bytes.Buffer
+	now := time.Unix(0, 0).UTC()
+	h := MustNewHistogram(MustNewUniformSample(100))
+	tap, failed := MustNewTapHistogram(h, &b, map[string]string{"host": "a", "db": "b"}, WithClock(func() time.Time { return now }))
+	tap.Update(1)
+	now = now.Add(time.Millisecond)
+	tap.UpdateBatch([]int64{2, 3})
+	if err := tap.Close(); err != nil {
+		t.Fatal(err)
+	}
+	const expected = "timestamp,value,db,host\n" +
+		"1970-01-01T00:00:00Z,1,b,a\n" +
+		"1970-01-01T00:00:00.001Z,2,b,a\n" +
+		"1970-01-01T00:00:00.001Z,3,b,a\n"
+	if expected != b.String() {
+		t.Errorf("b.String(): %q != %q\n", expected, b.String())
+	}
+	if count := h.Count(); 3 != count {
+		t.Errorf("h.Count(): 3 != %v\n", count)
+	}
+	if count := failed.Count(); 0 != count {
+		t.Errorf("failed.Count(): 0 != %v\n", count)
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n--; w.n < 0 {
+		return 0, errors.New("write failed")
+	}
+	return len(p), nil
+}
+
+func TestTapHistogramWriteError(t *testing.T) {
+	h := MustNewHistogram(MustNewUniformSample(100))
+	if _, _, err := NewTapHistogram(h, &failingWriter{}, nil); nil == err {
+		t.Error("NewTapHistogram(): no error")
+	}
+	tap, failed := MustNewTapHistogram(h, &failingWriter{n: 1}, nil)
+	tap.Update(1)
+	if err := tap.Close(); nil == err {
+		t.Error("tap.Close(): no error")
+	}
+	if count := failed.Count(); 1 != count {
+		t.Errorf("failed.Count(): 1 != %v\n", count)
+	}
+	if count := h.Count(); 1 != count {
+		t.Errorf("h.Count(): 1 != %v\n", count)
+	}
+}
+
+func TestTapHistogramSampling(t *testing.T) {
+	var b bytes.Buffer
+	h := MustNewHistogram(nil)
+	tap, _ := MustNewTapHistogram(h, &b, nil, WithTapSampling(10))
+	for i := 0; i < 1000; i++ {
+		tap.Update(int64(i))
+	}
+	if err := tap.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rows := strings.Count(b.String(), "\n") - 1; rows < 50 || rows > 200 {
+		t.Errorf("rows written: %v not near 100\n", rows)
+	}
+	if count := h.Count(); 1000 != count {
+		t.Errorf("h.Count(): 1000 != %v\n", count)
+	}
+}
+
+// A writer which blocks until released, after letting the header through.
+type blockingWriter struct {
+	bytes.Buffer
+	release chan struct{}
+	header  bool
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	if w.header {
+		<-w.release
+	}
+	w.header = true
+	return w.Buffer.Write(p)
+}
+
+func TestTapHistogramSlowWriter(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	h := MustNewHistogram(nil)
+	tap, dropped := MustNewTapHistogram(h, w, nil)
+	const n = 4 * tapBuffer
+	for i := 0; i < n; i++ {
+		tap.Update(int64(i))
+	}
+	if 0 == dropped.Count() {
+		t.Error("dropped.Count(): 0 with a blocked writer")
+	}
+	close(w.release)
+	if err := tap.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rows := int64(strings.Count(w.String(), "\n") - 1); n != rows+dropped.Count() {
+		t.Errorf("rows written + dropped: %v != %v + %v\n", n, rows, dropped.Count())
+	}
+	if count := h.Count(); n != count {
+		t.Errorf("h.Count(): %v != %v\n", n, count)
+	}
+}
+
+func TestTapTimer(t *testing.T) {
+	var b bytes.Buffer
+	now := time.Unix(0, 0).UTC()
+	tm, dropped := MustNewTapTimer(&b, map[string]string{"host": "a"},
+		WithClock(func() time.Time { return now }),
+		WithUnit(time.Millisecond),
+		WithInvalidPolicy(DropInvalid),
+	)
+	tm.Update(2 * time.Millisecond)
+	tm.Update(-time.Millisecond)
+	if err := tm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	const expected = "timestamp,value,host\n" +
+		"1970-01-01T00:00:00Z,2,a\n"
+	if expected != b.String() {
+		t.Errorf("b.String(): %q != %q\n", expected, b.String())
+	}
+	if count := tm.Count(); 1 != count {
+		t.Errorf("tm.Count(): 1 != %v\n", count)
+	}
+	if count := tm.Invalid(); 1 != count {
+		t.Errorf("tm.Invalid(): 1 != %v\n", count)
+	}
+	if count := dropped.Count(); 0 != count {
+		t.Errorf("dropped.Count(): 0 != %v\n", count)
+	}
+	if _, _, err := NewTapTimer(&b, nil, WithUnit(0)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewTapTimer(WithUnit(0)): %v is not ErrInvalidArgument", err)
+	}
+}
@@ -16,9 +16,13 @@ import (
 //			f()
 //		}
 //	}(e.Tick)
+//
+// A Runner does the same for several Tickables at once.
 const TickDuration = 5 * time.Second
 
 // Tickable defines the interface implemented by metrics that need to Tick.
+// Collectors and detectors which read their values on Tick embed it, and
+// like meters and timers rely on the caller to call it every TickDuration.
 type Tickable interface {
 	// Tick the clock to update the moving average.
 	Tick()
@@ -1,12 +1,30 @@
 package metrics
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // Timers capture the duration and rate of events.
 type Timer interface {
 	// Return the count of inputs.
 	Count() int64
 
+	// Return the time the timer's meter was created.
+	Created() time.Time
+
+	// Return the histogram of nanoseconds elapsed between consecutive events,
+	// or nil unless the timer was created with WithInterArrival.
+	InterArrival() Histogram
+
+	// Return the count of zero or negative durations seen, whatever the
+	// timer's InvalidPolicy did with them.
+	Invalid() int64
+
+	// Return the time of the last update, or the zero time if there was none
+	// or the timer was created without WithLastUpdate.
+	LastUpdate() time.Time
+
 	// Return the maximal value seen.
 	Max() int64
 
@@ -22,7 +40,18 @@ type Timer interface {
 	// Return a slice of arbitrary percentiles of all values seen.
 	Percentiles(ps []float64) []float64
 
-	// Return the meter's one-minute moving average rate of events.
+	// Return the fraction of durations seen which are less than or equal to d.
+	PercentileRank(d time.Duration) float64
+
+	// Pause the timer: ignore updates and ticks until Resume, and leave the
+	// time until then out of the mean rate.
+	Pause()
+
+	// Resume a paused timer.
+	Resume()
+
+	// Return the meter's one-minute moving average rate of events.  Rates are
+	// per second unless the timer was created with WithRateUnit.
 	Rate1() float64
 
 	// Return the meter's five-minute moving average rate of events.
@@ -34,6 +63,20 @@ type Timer interface {
 	// Return the meter's mean rate of events.
 	RateMean() float64
 
+	// Return the count of durations UpdateSince took for steps of the wall
+	// clock: negative ones, or ones longer than WithMaxDuration allows,
+	// measured between times of which one lacks a monotonic clock reading.
+	// Times from time.Now carry monotonic readings, others such as those from
+	// a clock set with WithClock may not.
+	Skewed() int64
+
+	// Return the timer's histogram and meter state as a read-only timer and
+	// clear them.  Each is swapped out atomically, but not all together, so an
+	// Update racing with it may show in the histogram of one snapshot and the
+	// meter of the next.  Calling Pause, Resume, Start, Update, UpdateSince,
+	// Tick or SnapshotAndReset on the returned timer panics.
+	SnapshotAndReset() Timer
+
 	// Return the standard deviation of all values seen.
 	StdDev() float64
 
@@ -49,12 +92,32 @@ type Timer interface {
 	Update(d time.Duration)
 
 	// Record the duration of an event that started at a time and ends now.
+	// It uses the monotonic clock when both times have readings of it.
+	// Otherwise negative durations are recorded as zero and, with
+	// WithMaxDuration, longer ones as the maximum, and both are counted as
+	// skewed.
 	UpdateSince(t time.Time)
 
 	// Tick the clock to update the moving average.
 	Tick()
 }
 
+// InvalidPolicy says what a Timer does with a zero or negative duration,
+// which usually comes from clock skew or a bug in measuring it.  Such
+// durations are counted whatever the policy.
+type InvalidPolicy int
+
+const (
+	// Record invalid durations like any other.
+	RecordInvalid InvalidPolicy = iota
+
+	// Drop invalid durations from both the histogram and the meter.
+	DropInvalid
+
+	// Record invalid durations as zero.
+	ClampInvalid
+)
+
 type capture struct {
 	timer Timer
 	start time.Time
@@ -66,29 +129,92 @@ func (c *capture) Stop() {
 
 // The standard implementation of a Timer uses a Histogram and Meter directly.
 type timer struct {
-	h Histogram
-	m Meter
+	h       Histogram
+	m       Meter
+	now     func() time.Time
+	unit    time.Duration
+	policy  InvalidPolicy
+	invalid Counter
+	skewed  Counter
+	max     time.Duration // longest plausible wall clock duration, 0 for any
+	paused  atomic.Bool
 }
 
 // Create a new timer with the given Histogram and Meter.
 func NewCustomTimer(h Histogram, m Meter) Timer {
-	return &timer{h, m}
+	return &timer{h: h, m: m, now: time.Now, unit: time.Nanosecond, invalid: NewCounter(), skewed: NewCounter()}
 }
 
 // Create a new timer with a standard histogram and meter.  The histogram
 // will use an exponentially-decaying sample with the same reservoir size
-// and alpha as UNIX load averages.
-func NewTimer() Timer {
+// and alpha as UNIX load averages unless WithReservoirSize or WithAlpha
+// options say otherwise, or no sample at all with WithoutReservoir.  Options
+// are passed on to the sample, histogram and meter.
+func NewTimer(opts ...Option) (Timer, error) {
+	o := newOptions(opts)
+	if err := firstError(
+		checkUnit(o.unit),
+		checkInvalidPolicy(o.invalid),
+		checkMaxDuration(o.maxDuration),
+	); err != nil {
+		return nil, err
+	}
+	var s Sample
+	if !o.noReservoir {
+		var err error
+		if s, err = NewExpDecaySample(o.reservoirSize, o.alpha, opts...); err != nil {
+			return nil, err
+		}
+	}
+	h, err := NewHistogram(s, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewMeter(opts...)
+	if err != nil {
+		return nil, err
+	}
 	return &timer{
-		NewHistogram(NewExpDecaySample(1028, 0.015)),
-		NewMeter(),
+		h:       h,
+		m:       m,
+		now:     o.now,
+		unit:    o.unit,
+		policy:  o.invalid,
+		invalid: NewCounter(),
+		skewed:  NewCounter(),
+		max:     o.maxDuration,
+	}, nil
+}
+
+// Like NewTimer but panics if the options are invalid.
+func MustNewTimer(opts ...Option) Timer {
+	t, err := NewTimer(opts...)
+	if err != nil {
+		panic(err)
 	}
+	return t
 }
 
 func (t *timer) Count() int64 {
 	return t.h.Count()
 }
 
+func (t *timer) Created() time.Time {
+	return t.m.Created()
+}
+
+func (t *timer) Invalid() int64 {
+	return t.invalid.Count()
+}
+
+func (t *timer) LastUpdate() time.Time {
+	return t.m.LastUpdate()
+}
+
+func (t *timer) InterArrival() Histogram {
+	return t.m.InterArrival()
+}
+
 func (t *timer) Max() int64 {
 	return t.h.Max()
 }
@@ -109,6 +235,20 @@ func (t *timer) Percentiles(ps []float64) []float64 {
 	return t.h.Percentiles(ps)
 }
 
+func (t *timer) PercentileRank(d time.Duration) float64 {
+	return t.h.PercentileRank(int64(d / t.unit))
+}
+
+func (t *timer) Pause() {
+	t.paused.Store(true)
+	t.m.Pause()
+}
+
+func (t *timer) Resume() {
+	t.paused.Store(false)
+	t.m.Resume()
+}
+
 func (t *timer) Rate1() float64 {
 	return t.m.Rate1()
 }
@@ -125,6 +265,20 @@ func (t *timer) RateMean() float64 {
 	return t.m.RateMean()
 }
 
+func (t *timer) SnapshotAndReset() Timer {
+	return &timerSnapshot{
+		h:       t.h.SnapshotAndReset(),
+		m:       t.m.SnapshotAndReset(),
+		unit:    t.unit,
+		invalid: t.invalid.SnapshotAndReset(),
+		skewed:  t.skewed.SnapshotAndReset(),
+	}
+}
+
+func (t *timer) Skewed() int64 {
+	return t.skewed.Count()
+}
+
 func (t *timer) StdDev() float64 {
 	return t.h.StdDev()
 }
@@ -133,21 +287,136 @@ func (t *timer) Start() interface {
 	Stop()
 } {
 	return &capture{
-		start: time.Now(),
+		start: t.now(),
 		timer: t,
 	}
 }
 
 func (t *timer) Update(d time.Duration) {
-	t.h.Update(int64(d))
+	if t.paused.Load() {
+		return
+	}
+	if d <= 0 {
+		t.invalid.Inc(1)
+		switch t.policy {
+		case DropInvalid:
+			return
+		case ClampInvalid:
+			d = 0
+		}
+	}
+	t.h.Update(int64(d / t.unit))
 	t.m.Mark(1)
 }
 
 func (t *timer) UpdateSince(ts time.Time) {
-	t.h.Update(int64(time.Since(ts)))
-	t.m.Mark(1)
+	now := t.now()
+	d := now.Sub(ts)
+	if !hasMonotonic(now) || !hasMonotonic(ts) {
+		switch {
+		case d < 0:
+			t.skewed.Inc(1)
+			d = 0
+		case t.max > 0 && d > t.max:
+			t.skewed.Inc(1)
+			d = t.max
+		}
+	}
+	t.Update(d)
 }
 
 func (t *timer) Tick() {
 	t.m.Tick()
 }
+
+// A read-only timer made of histogram and meter snapshots.
+type timerSnapshot struct {
+	h       Histogram
+	m       Meter
+	unit    time.Duration
+	invalid Counter
+	skewed  Counter
+}
+
+func (t *timerSnapshot) Count() int64 { return t.h.Count() }
+
+func (t *timerSnapshot) Created() time.Time { return t.m.Created() }
+
+func (t *timerSnapshot) Invalid() int64 { return t.invalid.Count() }
+
+func (t *timerSnapshot) LastUpdate() time.Time { return t.m.LastUpdate() }
+
+func (t *timerSnapshot) InterArrival() Histogram { return t.m.InterArrival() }
+
+func (t *timerSnapshot) Max() int64 { return t.h.Max() }
+
+func (t *timerSnapshot) Mean() float64 { return t.h.Mean() }
+
+func (t *timerSnapshot) Min() int64 { return t.h.Min() }
+
+func (t *timerSnapshot) Percentile(p float64) float64 { return t.h.Percentile(p) }
+
+func (t *timerSnapshot) Percentiles(ps []float64) []float64 { return t.h.Percentiles(ps) }
+
+func (t *timerSnapshot) PercentileRank(d time.Duration) float64 {
+	return t.h.PercentileRank(int64(d / t.unit))
+}
+
+func (t *timerSnapshot) Pause() {
+	panic("metrics: Pause called on a timer snapshot")
+}
+
+func (t *timerSnapshot) Resume() {
+	panic("metrics: Resume called on a timer snapshot")
+}
+
+func (t *timerSnapshot) Rate1() float64 { return t.m.Rate1() }
+
+func (t *timerSnapshot) Rate5() float64 { return t.m.Rate5() }
+
+func (t *timerSnapshot) Rate15() float64 { return t.m.Rate15() }
+
+func (t *timerSnapshot) RateMean() float64 { return t.m.RateMean() }
+
+func (t *timerSnapshot) SnapshotAndReset() Timer {
+	panic("metrics: SnapshotAndReset called on a timer snapshot")
+}
+
+func (t *timerSnapshot) Skewed() int64 { return t.skewed.Count() }
+
+func (t *timerSnapshot) StdDev() float64 { return t.h.StdDev() }
+
+func (t *timerSnapshot) Start() interface {
+	Stop()
+} {
+	panic("metrics: Start called on a timer snapshot")
+}
+
+func (t *timerSnapshot) Update(time.Duration) {
+	panic("metrics: Update called on a timer snapshot")
+}
+
+func (t *timerSnapshot) UpdateSince(time.Time) {
+	panic("metrics: UpdateSince called on a timer snapshot")
+}
+
+func (t *timerSnapshot) Tick() {
+	panic("metrics: Tick called on a timer snapshot")
+}
+
+// Report whether t carries a monotonic clock reading, which Round(0) strips.
+func hasMonotonic(t time.Time) bool {
+	return t != t.Round(0)
+}
+
+func (t *timer) String() string { return formatTimer(t) }
+
+func (t *timerSnapshot) String() string { return formatTimer(t) }
+
+func (t *timer) MarshalJSON() ([]byte, error) { return marshalTimer(t) }
+
+func (t *timer) MarshalText() ([]byte, error) { return []byte(formatTimer(t)), nil }
+
+func (t *timerSnapshot) MarshalJSON() ([]byte, error) { return marshalTimer(t) }
+
+func (t *timerSnapshot) MarshalText() ([]byte, error) { return []byte(formatTimer(t)), nil }
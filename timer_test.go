@@ -1,13 +1,14 @@
 package metrics
 
 import (
+	"errors"
 	"math"
 	"testing"
 	"time"
 )
 
 func TestTimerZero(t *testing.T) {
-	tm := NewTimer()
+	tm := MustNewTimer()
 	if count := tm.Count(); 0 != count {
 		t.Errorf("tm.Count(): 0 != %v\n", count)
 	}
@@ -48,7 +49,7 @@ func TestTimerZero(t *testing.T) {
 }
 
 func TestTimerExtremes(t *testing.T) {
-	tm := NewTimer()
+	tm := MustNewTimer()
 	tm.Update(math.MaxInt64)
 	tm.Update(0)
 	if stdDev := tm.StdDev(); 6.521908912666392e18 != stdDev {
@@ -57,7 +58,7 @@ func TestTimerExtremes(t *testing.T) {
 }
 
 func TestTimerStartStop(t *testing.T) {
-	tm := NewTimer()
+	tm := MustNewTimer()
 	func() {
 		defer tm.Start().Stop()
 		time.Sleep(50e6)
@@ -68,7 +69,7 @@ func TestTimerStartStop(t *testing.T) {
 }
 
 func TestTimerRate1(t *testing.T) {
-	tm := NewTimer()
+	tm := MustNewTimer()
 	tm.Update(3 * time.Second)
 	tm.Tick()
 	const expected = 0.2
@@ -76,3 +77,197 @@ func TestTimerRate1(t *testing.T) {
 		t.Errorf("tm.Rate1(): %v != %v\n", expected, r1)
 	}
 }
+
+func TestTimerUnit(t *testing.T) {
+	tm := MustNewTimer(WithUnit(time.Millisecond))
+	tm.Update(1500 * time.Millisecond)
+	if max := tm.Max(); 1500 != max {
+		t.Errorf("tm.Max(): 1500 != %v\n", max)
+	}
+}
+
+func TestTimerWithoutReservoir(t *testing.T) {
+	tm := MustNewTimer(WithoutReservoir())
+	tm.Update(time.Second)
+	tm.Update(3 * time.Second)
+	if count := tm.Count(); 2 != count {
+		t.Errorf("tm.Count(): 2 != %v\n", count)
+	}
+	if mean := tm.Mean(); 2e9 != mean {
+		t.Errorf("tm.Mean(): 2e9 != %v\n", mean)
+	}
+	if p := tm.Percentile(0.5); 0 != p {
+		t.Errorf("tm.Percentile(0.5): 0 != %v\n", p)
+	}
+	if max := tm.SnapshotAndReset().Max(); int64(3*time.Second) != max {
+		t.Errorf("tm.SnapshotAndReset().Max(): %v != %v\n", int64(3*time.Second), max)
+	}
+}
+
+func TestTimerUpdateSinceClock(t *testing.T) {
+	now := time.Unix(0, 0)
+	tm := MustNewTimer(WithClock(func() time.Time { return now }))
+	start := now
+	now = now.Add(time.Second)
+	tm.UpdateSince(start)
+	if max := tm.Max(); int64(time.Second) != max {
+		t.Errorf("tm.Max(): %v != %v\n", int64(time.Second), max)
+	}
+}
+
+func TestTimerSkewed(t *testing.T) {
+	tm := MustNewTimer()
+	tm.UpdateSince(time.Now())
+	tm.Start().Stop()
+	if skewed := tm.Skewed(); 0 != skewed {
+		t.Errorf("tm.Skewed(): 0 != %v\n", skewed)
+	}
+	tm.UpdateSince(time.Now().Round(0))
+	if skewed := tm.Skewed(); 0 != skewed {
+		t.Errorf("tm.Skewed() without a monotonic reading: 0 != %v\n", skewed)
+	}
+	tm.UpdateSince(time.Now().Round(0).Add(time.Hour))
+	if skewed := tm.SnapshotAndReset().Skewed(); 1 != skewed {
+		t.Errorf("tm.SnapshotAndReset().Skewed(): 1 != %v\n", skewed)
+	}
+}
+
+func TestTimerSkewedClock(t *testing.T) {
+	now := time.Unix(0, 0)
+	tm := MustNewTimer(
+		WithClock(func() time.Time { return now }),
+		WithMaxDuration(time.Minute),
+		WithInvalidPolicy(DropInvalid),
+	)
+	tm.UpdateSince(now.Add(-time.Second))
+	tm.UpdateSince(now.Add(time.Second))
+	tm.UpdateSince(now.Add(-time.Hour))
+	if skewed := tm.Skewed(); 2 != skewed {
+		t.Errorf("tm.Skewed(): 2 != %v\n", skewed)
+	}
+	if count := tm.Count(); 2 != count {
+		t.Errorf("tm.Count(): 2 != %v\n", count)
+	}
+	if max := tm.Max(); int64(time.Minute) != max {
+		t.Errorf("tm.Max(): %v != %v\n", int64(time.Minute), max)
+	}
+	if _, err := NewTimer(WithMaxDuration(-time.Second)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("NewTimer(WithMaxDuration(-1s)): %v\n", err)
+	}
+}
+
+func TestTimerPause(t *testing.T) {
+	tm := MustNewTimer()
+	tm.Pause()
+	tm.Update(time.Second)
+	tm.Resume()
+	tm.Update(2 * time.Second)
+	if count := tm.Count(); 1 != count {
+		t.Errorf("tm.Count(): 1 != %v\n", count)
+	}
+	if min := tm.Min(); int64(2*time.Second) != min {
+		t.Errorf("tm.Min(): %v != %v\n", int64(2*time.Second), min)
+	}
+}
+
+func TestTimerInvalidOptions(t *testing.T) {
+	for _, opt := range []Option{
+		WithReservoirSize(0),
+		WithAlpha(0),
+		WithTickInterval(0),
+		WithUnit(0),
+		WithClock(nil),
+		WithInvalidPolicy(-1),
+	} {
+		if _, err := NewTimer(opt); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("NewTimer: %v is not ErrInvalidArgument", err)
+		}
+	}
+}
+
+func TestTimerInvalidPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy   InvalidPolicy
+		count    int64
+		min, max int64
+	}{
+		{RecordInvalid, 3, -1, 2},
+		{DropInvalid, 1, 2, 2},
+		{ClampInvalid, 3, 0, 2},
+	} {
+		tm := MustNewTimer(WithInvalidPolicy(tc.policy))
+		tm.Update(2)
+		tm.Update(0)
+		tm.Update(-1)
+		if count := tm.Count(); tc.count != count {
+			t.Errorf("policy %v: tm.Count(): %v != %v\n", tc.policy, tc.count, count)
+		}
+		if min := tm.Min(); tc.min != min {
+			t.Errorf("policy %v: tm.Min(): %v != %v\n", tc.policy, tc.min, min)
+		}
+		if max := tm.Max(); tc.max != max {
+			t.Errorf("policy %v: tm.Max(): %v != %v\n", tc.policy, tc.max, max)
+		}
+		if invalid := tm.SnapshotAndReset().Invalid(); 2 != invalid {
+			t.Errorf("policy %v: tm.SnapshotAndReset().Invalid(): 2 != %v\n", tc.policy, invalid)
+		}
+		if invalid := tm.Invalid(); 0 != invalid {
+			t.Errorf("policy %v: tm.Invalid(): 0 != %v\n", tc.policy, invalid)
+		}
+	}
+}
+
+func TestMustNewTimerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustNewTimer(WithReservoirSize(0)): expected panic")
+		}
+	}()
+	MustNewTimer(WithReservoirSize(0))
+}
+
+func TestTimerSnapshotAndReset(t *testing.T) {
+	tm := MustNewTimer()
+	tm.Update(time.Second)
+	tm.Update(3 * time.Second)
+	s := tm.SnapshotAndReset()
+	if count := s.Count(); 2 != count {
+		t.Errorf("s.Count(): 2 != %v\n", count)
+	}
+	if mean := s.Mean(); float64(2*time.Second) != mean {
+		t.Errorf("s.Mean(): %v != %v\n", float64(2*time.Second), mean)
+	}
+	if count := tm.Count(); 0 != count {
+		t.Errorf("tm.Count(): 0 != %v\n", count)
+	}
+}
+
+func TestTimerPercentileRank(t *testing.T) {
+	tm := MustNewTimer(WithUnit(time.Millisecond))
+	for _, d := range []time.Duration{50, 80, 120, 300} {
+		tm.Update(d * time.Millisecond)
+	}
+	if r := tm.PercentileRank(100 * time.Millisecond); 0.5 != r {
+		t.Errorf("tm.PercentileRank(100ms): 0.5 != %v\n", r)
+	}
+}
+
+func TestTimerTimestamps(t *testing.T) {
+	now := time.Unix(100, 0)
+	tm := MustNewTimer(WithClock(func() time.Time { return now }), WithLastUpdate())
+	if ts := tm.Created(); !ts.Equal(now) {
+		t.Errorf("tm.Created(): %v != %v\n", now, ts)
+	}
+	if ts := tm.LastUpdate(); !ts.IsZero() {
+		t.Errorf("tm.LastUpdate(): zero time != %v\n", ts)
+	}
+	now = now.Add(time.Minute)
+	tm.Update(time.Second)
+	if ts := tm.LastUpdate(); !ts.Equal(now) {
+		t.Errorf("tm.LastUpdate(): %v != %v\n", now, ts)
+	}
+	s := tm.SnapshotAndReset()
+	if ts := s.LastUpdate(); !ts.Equal(now) {
+		t.Errorf("s.LastUpdate(): %v != %v\n", now, ts)
+	}
+}
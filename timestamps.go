@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Unix nanoseconds of a metric which was never updated; no clock reading
+// is this far in the past.
+const neverUpdated = math.MinInt64
+
+// Creation and last update times of a metric.  Embedding it provides the
+// Created and LastUpdate methods of the standard metrics.  Last updates are
+// only recorded for metrics created with WithLastUpdate.
+type timestamps struct {
+	created time.Time
+	updated atomic.Int64     // Unix nanoseconds
+	now     func() time.Time // nil unless last updates are recorded
+}
+
+// Set the creation time from the clock in o, or time.Now if it is nil, and
+// prepare to record last updates if o asks for it.
+func (ts *timestamps) init(o *options) {
+	now := o.now
+	if now == nil {
+		now = time.Now
+	}
+	ts.created = now()
+	ts.updated.Store(neverUpdated)
+	if o.lastUpdate {
+		ts.now = now
+	}
+}
+
+// Return the time the metric was created.
+func (ts *timestamps) Created() time.Time {
+	return ts.created
+}
+
+// Return the time the metric was last updated, or the zero time if it never
+// was or the metric does not record last updates.
+func (ts *timestamps) LastUpdate() time.Time {
+	n := ts.updated.Load()
+	if neverUpdated == n {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Record an update now, if the metric records last updates.
+func (ts *timestamps) touch() {
+	if ts.now != nil {
+		ts.updated.Store(ts.now().UnixNano())
+	}
+}
+
+// Return a copy safe to embed in a snapshot.
+func (ts *timestamps) snapshot() frozenTimestamps {
+	return frozenTimestamps{created: ts.created, updated: ts.LastUpdate()}
+}
+
+// Creation and last update times of a snapshot.
+type frozenTimestamps struct {
+	created, updated time.Time
+}
+
+func (ts *frozenTimestamps) Created() time.Time { return ts.created }
+
+func (ts *frozenTimestamps) LastUpdate() time.Time { return ts.updated }